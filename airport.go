@@ -0,0 +1,343 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// RunwayLighting is the runway edge light intensity from APT_RWY.RWY_LGT_CODE.
+type RunwayLighting int
+
+const (
+	RunwayLightingUnknown     RunwayLighting = iota
+	RunwayLightingNone                       // NONE
+	RunwayLightingLow                        // LOW
+	RunwayLightingMedium                     // MED
+	RunwayLightingHigh                       // HIGH
+	RunwayLightingNonstandard                // NSTD
+	RunwayLightingPerimeter                  // PERI, perimeter lights (water runways)
+	RunwayLightingStrobe                     // STRB
+	RunwayLightingFlood                      // FLD
+)
+
+var runwayLightingCodes = map[string]RunwayLighting{
+	"NONE": RunwayLightingNone,
+	"LOW":  RunwayLightingLow,
+	"MED":  RunwayLightingMedium,
+	"HIGH": RunwayLightingHigh,
+	"NSTD": RunwayLightingNonstandard,
+	"PERI": RunwayLightingPerimeter,
+	"STRB": RunwayLightingStrobe,
+	"FLD":  RunwayLightingFlood,
+}
+
+// ParseRunwayLighting converts an RWY_LGT_CODE value. Unrecognized and empty
+// codes return RunwayLightingUnknown.
+func ParseRunwayLighting(code string) RunwayLighting {
+	return runwayLightingCodes[code]
+}
+
+// String returns the FAA code for the lighting level.
+func (l RunwayLighting) String() string {
+	for code, v := range runwayLightingCodes {
+		if v == l {
+			return code
+		}
+	}
+	return ""
+}
+
+// ApproachLighting is an approach light system code from
+// APT_RWY_END.APCH_LGT_SYSTEM_CODE, e.g. "MALSR". The constants cover the
+// common systems; other published codes are kept as is.
+type ApproachLighting string
+
+const (
+	ApproachLightingNone        ApproachLighting = "NONE"
+	ApproachLightingALSF1       ApproachLighting = "ALSF1"
+	ApproachLightingALSF2       ApproachLighting = "ALSF2"
+	ApproachLightingMALS        ApproachLighting = "MALS"
+	ApproachLightingMALSF       ApproachLighting = "MALSF"
+	ApproachLightingMALSR       ApproachLighting = "MALSR"
+	ApproachLightingSALS        ApproachLighting = "SALS"
+	ApproachLightingSALSF       ApproachLighting = "SALSF"
+	ApproachLightingSSALS       ApproachLighting = "SSALS"
+	ApproachLightingSSALF       ApproachLighting = "SSALF"
+	ApproachLightingSSALR       ApproachLighting = "SSALR"
+	ApproachLightingODALS       ApproachLighting = "ODALS"
+	ApproachLightingRAIL        ApproachLighting = "RAIL"
+	ApproachLightingLDIN        ApproachLighting = "LDIN"
+	ApproachLightingNonstandard ApproachLighting = "NSTD"
+)
+
+// Airport is an APT_BASE row with its runways, contacts, attendance schedule
+// and remarks.
+type Airport struct {
+	SiteNo      string // SITE_NO, the unique landing facility site number
+	ID          string // ARPT_ID, the FAA location identifier
+	ICAO        string // ICAO_ID
+	Name        string // ARPT_NAME
+	SiteType    string // SITE_TYPE_CODE, e.g. "A" for airport, "H" for heliport
+	City        string
+	State       string // STATE_CODE
+	Country     string // COUNTRY_CODE
+	Ownership   string // OWNERSHIP_TYPE_CODE
+	FacilityUse string // FACILITY_USE_CODE
+	Status      string // ARPT_STATUS
+	ARTCC       string // RESP_ARTCC_ID
+
+	Lat, Lon  float64
+	Elevation sql.NullFloat64 // ELEV, feet MSL
+	MagVar    sql.NullFloat64 // degrees, east positive
+	TPA       sql.NullFloat64 // traffic pattern altitude, feet AGL
+
+	FuelTypes string // FUEL_TYPES as published
+
+	Runways    []Runway
+	Contacts   []Contact
+	Attendance []Attendance
+	Remarks    []Remark
+}
+
+// Runway is an APT_RWY row with its ends.
+type Runway struct {
+	ID        string          // RWY_ID, e.g. "10L/28R"
+	Length    sql.NullFloat64 // RWY_LEN, feet
+	Width     sql.NullFloat64 // RWY_WIDTH, feet
+	Surface   string          // SURFACE_TYPE_CODE, e.g. "ASPH-CONC"
+	Condition string          // COND
+	Lighting  RunwayLighting  // RWY_LGT_CODE
+	Ends      []RunwayEnd
+}
+
+// RunwayEnd is an APT_RWY_END row. Declared distances are in feet.
+type RunwayEnd struct {
+	ID               string          // RWY_END_ID, e.g. "10L"
+	TrueAlignment    sql.NullFloat64 // degrees true
+	ILSType          string
+	RightTraffic     bool // RIGHT_HAND_TRAFFIC_PAT_FLAG
+	Lat, Lon         sql.NullFloat64
+	Elevation        sql.NullFloat64 // RWY_END_ELEV, feet MSL
+	TDZElevation     sql.NullFloat64 // TDZ_ELEV, feet MSL
+	ThresholdHeight  sql.NullFloat64 // THR_CROSSING_HGT, feet AGL
+	GlidePathAngle   sql.NullFloat64 // VISUAL_GLIDE_PATH_ANGLE, degrees
+	DisplacedLength  sql.NullFloat64 // DISPLACED_THR_LEN, feet
+	VGSI             string          // VGSI_CODE, e.g. "P4L"
+	ApproachLighting ApproachLighting
+	EndLights        bool // RWY_END_LGTS_FLAG
+	CenterlineLights bool // CNTRLN_LGTS_AVBL_FLAG
+	TDZLights        bool // TDZ_LGT_AVBL_FLAG
+
+	TORA sql.NullFloat64 // TKOF_RUN_AVBL, takeoff run available
+	TODA sql.NullFloat64 // TKOF_DIST_AVBL, takeoff distance available
+	ASDA sql.NullFloat64 // ACLT_STOP_DIST_AVBL, accelerate-stop distance available
+	LDA  sql.NullFloat64 // LNDG_DIST_AVBL, landing distance available
+}
+
+// Contact is an APT_CON row.
+type Contact struct {
+	Title    string
+	Name     string
+	Address1 string
+	Address2 string
+	City     string // TITLE_CITY
+	State    string
+	ZipCode  string
+	Phone    string
+}
+
+// Attendance is an APT_ATT schedule entry, kept as published.
+type Attendance struct {
+	Seq   int
+	Month string // e.g. "ALL", "MAY-NOV", "UNATNDD"
+	Day   string // e.g. "MON-FRI"
+	Hour  string // e.g. "0800-1700"
+}
+
+// Schedule parses the entry's month, day and hour columns. The schedule's
+// Position is not set.
+func (at Attendance) Schedule() Schedule {
+	return ParseSchedule(strings.Join([]string{at.Month, at.Day, at.Hour}, " "))
+}
+
+// AttendanceSchedule combines the airport's attendance entries into one
+// schedule positioned at the airport, so sunrise and sunset hours can be
+// evaluated. It is unparsed if any entry is, and unparsed with an empty Raw
+// when the airport has no attendance entries.
+func (a *Airport) AttendanceSchedule() Schedule {
+	sched := Schedule{Parsed: len(a.Attendance) > 0, Position: &LatLon{a.Lat, a.Lon}}
+	var raw []string
+	for _, at := range a.Attendance {
+		s := at.Schedule()
+		raw = append(raw, s.Raw)
+		sched.Periods = append(sched.Periods, s.Periods...)
+		sched.Parsed = sched.Parsed && s.Parsed
+	}
+	sched.Raw = strings.Join(raw, "; ")
+	if !sched.Parsed {
+		sched.Periods = nil
+	}
+	return sched
+}
+
+// Remark is a remark row from one of the *_RMK tables.
+type Remark struct {
+	TabName string // TAB_NAME, the table the remark refers to
+	Column  string // REF_COL_NAME, the column the remark refers to
+	Element string // ELEMENT, e.g. a runway or runway end id
+	Seq     int    // REF_COL_SEQ_NO
+	Text    string
+}
+
+// signedMagVar applies a hemisphere to a magnetic variation, returning east
+// variation as positive and west as negative.
+func signedMagVar(v sql.NullFloat64, hemis string) sql.NullFloat64 {
+	if v.Valid && hemis == "W" {
+		v.Float64 = -v.Float64
+	}
+	return v
+}
+
+// LookupAirport returns the airport identified by an FAA location identifier
+// (ARPT_ID, e.g. "BOI"), an ICAO code (e.g. "KBOI") or a SITE_NO, together
+// with its runways, runway ends, contacts, attendance and remarks. It returns
+// ErrNotFound when nothing matches.
+func LookupAirport(db *sql.DB, ident string) (*Airport, error) {
+	recs, err := queryRecords(db, `SELECT * FROM "APT_BASE"
+WHERE "ARPT_ID" = ?1 OR "ICAO_ID" = ?1 OR "SITE_NO" = ?1
+ORDER BY "ARPT_ID" = ?1 DESC, "ICAO_ID" = ?1 DESC
+LIMIT 1`, ident)
+	if err != nil {
+		return nil, fmt.Errorf("lookup airport %s: %w", ident, err)
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("airport %s: %w", ident, ErrNotFound)
+	}
+	r := recs[0]
+	a := &Airport{
+		SiteNo:      r.str("SITE_NO"),
+		ID:          r.str("ARPT_ID"),
+		ICAO:        r.str("ICAO_ID"),
+		Name:        r.str("ARPT_NAME"),
+		SiteType:    r.str("SITE_TYPE_CODE"),
+		City:        r.str("CITY"),
+		State:       r.str("STATE_CODE"),
+		Country:     r.str("COUNTRY_CODE"),
+		Ownership:   r.str("OWNERSHIP_TYPE_CODE"),
+		FacilityUse: r.str("FACILITY_USE_CODE"),
+		Status:      r.str("ARPT_STATUS"),
+		ARTCC:       r.str("RESP_ARTCC_ID"),
+		Lat:         r.float("LAT_DECIMAL").Float64,
+		Lon:         r.float("LONG_DECIMAL").Float64,
+		Elevation:   r.float("ELEV"),
+		MagVar:      signedMagVar(r.float("MAG_VARN"), r.str("MAG_HEMIS")),
+		TPA:         r.float("TPA"),
+		FuelTypes:   r.str("FUEL_TYPES"),
+	}
+
+	if err := loadRunways(db, a); err != nil {
+		return nil, err
+	}
+
+	cons, err := queryRecords(db, `SELECT * FROM "APT_CON" WHERE "SITE_NO" = ? ORDER BY rowid`, a.SiteNo)
+	if err != nil {
+		return nil, fmt.Errorf("contacts for %s: %w", a.ID, err)
+	}
+	for _, c := range cons {
+		a.Contacts = append(a.Contacts, Contact{
+			Title:    c.str("TITLE"),
+			Name:     c.str("NAME"),
+			Address1: c.str("ADDRESS1"),
+			Address2: c.str("ADDRESS2"),
+			City:     c.str("TITLE_CITY"),
+			State:    c.str("STATE"),
+			ZipCode:  c.str("ZIP_CODE"),
+			Phone:    c.str("PHONE_NO"),
+		})
+	}
+
+	atts, err := queryRecords(db, `SELECT * FROM "APT_ATT" WHERE "SITE_NO" = ? ORDER BY "SKED_SEQ_NO"`, a.SiteNo)
+	if err != nil {
+		return nil, fmt.Errorf("attendance for %s: %w", a.ID, err)
+	}
+	for _, at := range atts {
+		a.Attendance = append(a.Attendance, Attendance{
+			Seq:   int(at.float("SKED_SEQ_NO").Float64),
+			Month: at.str("MONTH"),
+			Day:   at.str("DAY"),
+			Hour:  at.str("HOUR"),
+		})
+	}
+
+	rmks, err := queryRecords(db, `SELECT * FROM "APT_RMK" WHERE "SITE_NO" = ? ORDER BY rowid`, a.SiteNo)
+	if err != nil {
+		return nil, fmt.Errorf("remarks for %s: %w", a.ID, err)
+	}
+	for _, rm := range rmks {
+		a.Remarks = append(a.Remarks, remarkOf(rm))
+	}
+
+	return a, nil
+}
+
+func remarkOf(r record) Remark {
+	return Remark{
+		TabName: r.str("TAB_NAME"),
+		Column:  r.str("REF_COL_NAME"),
+		Element: r.str("ELEMENT"),
+		Seq:     int(r.float("REF_COL_SEQ_NO").Float64),
+		Text:    r.str("REMARK"),
+	}
+}
+
+func loadRunways(db *sql.DB, a *Airport) error {
+	rwys, err := queryRecords(db, `SELECT * FROM "APT_RWY" WHERE "SITE_NO" = ? ORDER BY "RWY_ID"`, a.SiteNo)
+	if err != nil {
+		return fmt.Errorf("runways for %s: %w", a.ID, err)
+	}
+	ends, err := queryRecords(db, `SELECT * FROM "APT_RWY_END" WHERE "SITE_NO" = ? ORDER BY "RWY_ID", "RWY_END_ID"`, a.SiteNo)
+	if err != nil {
+		return fmt.Errorf("runway ends for %s: %w", a.ID, err)
+	}
+
+	for _, r := range rwys {
+		rwy := Runway{
+			ID:        r.str("RWY_ID"),
+			Length:    r.float("RWY_LEN"),
+			Width:     r.float("RWY_WIDTH"),
+			Surface:   r.str("SURFACE_TYPE_CODE"),
+			Condition: r.str("COND"),
+			Lighting:  ParseRunwayLighting(r.str("RWY_LGT_CODE")),
+		}
+		for _, e := range ends {
+			if e.str("RWY_ID") != rwy.ID {
+				continue
+			}
+			rwy.Ends = append(rwy.Ends, RunwayEnd{
+				ID:               e.str("RWY_END_ID"),
+				TrueAlignment:    e.float("TRUE_ALIGNMENT"),
+				ILSType:          e.str("ILS_TYPE"),
+				RightTraffic:     e.flag("RIGHT_HAND_TRAFFIC_PAT_FLAG"),
+				Lat:              e.float("LAT_DECIMAL"),
+				Lon:              e.float("LONG_DECIMAL"),
+				Elevation:        e.float("RWY_END_ELEV"),
+				TDZElevation:     e.float("TDZ_ELEV"),
+				ThresholdHeight:  e.float("THR_CROSSING_HGT"),
+				GlidePathAngle:   e.float("VISUAL_GLIDE_PATH_ANGLE"),
+				DisplacedLength:  e.float("DISPLACED_THR_LEN"),
+				VGSI:             e.str("VGSI_CODE"),
+				ApproachLighting: ApproachLighting(e.str("APCH_LGT_SYSTEM_CODE")),
+				EndLights:        e.flag("RWY_END_LGTS_FLAG"),
+				CenterlineLights: e.flag("CNTRLN_LGTS_AVBL_FLAG"),
+				TDZLights:        e.flag("TDZ_LGT_AVBL_FLAG"),
+				TORA:             e.float("TKOF_RUN_AVBL"),
+				TODA:             e.float("TKOF_DIST_AVBL"),
+				ASDA:             e.float("ACLT_STOP_DIST_AVBL"),
+				LDA:              e.float("LNDG_DIST_AVBL"),
+			})
+		}
+		a.Runways = append(a.Runways, rwy)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package nasr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRunwayLighting(t *testing.T) {
+	tests := []struct {
+		code string
+		want RunwayLighting
+	}{
+		{"MED", RunwayLightingMedium},
+		{"HIGH", RunwayLightingHigh},
+		{"NSTD", RunwayLightingNonstandard},
+		{"PERI", RunwayLightingPerimeter},
+		{"", RunwayLightingUnknown},
+		{"BOGUS", RunwayLightingUnknown},
+	}
+	for _, tt := range tests {
+		got := ParseRunwayLighting(tt.code)
+		if got != tt.want {
+			t.Errorf("ParseRunwayLighting(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+		if tt.want != RunwayLightingUnknown && got.String() != tt.code {
+			t.Errorf("%v.String() = %q, want %q", got, got.String(), tt.code)
+		}
+	}
+}
+
+func TestLookupAirport(t *testing.T) {
+	db := openTestDB(t)
+
+	for _, ident := range []string{"BOI", "KBOI", "04149."} {
+		a, err := LookupAirport(db, ident)
+		if err != nil {
+			t.Fatalf("LookupAirport(%q): %v", ident, err)
+		}
+		if a.ID != "BOI" || a.ICAO != "KBOI" || a.SiteNo != "04149." {
+			t.Errorf("LookupAirport(%q) = %s/%s/%s", ident, a.ID, a.ICAO, a.SiteNo)
+		}
+		if !a.MagVar.Valid || a.MagVar.Float64 != 13 {
+			t.Errorf("LookupAirport(%q) MagVar = %+v, want 13 east", ident, a.MagVar)
+		}
+	}
+
+	a, err := LookupAirport(db, "0J0")
+	if err != nil {
+		t.Fatalf("LookupAirport(0J0): %v", err)
+	}
+	if a.Elevation.Float64 != 468.3 {
+		t.Errorf("Elevation = %v, want 468.3", a.Elevation)
+	}
+	if a.MagVar.Float64 != -1 {
+		t.Errorf("MagVar = %v, want -1 (west)", a.MagVar.Float64)
+	}
+	if len(a.Runways) != 1 {
+		t.Fatalf("expected 1 runway, got %d", len(a.Runways))
+	}
+	rwy := a.Runways[0]
+	if rwy.ID != "18/36" || rwy.Length.Float64 != 5000 || rwy.Lighting != RunwayLightingMedium {
+		t.Errorf("runway = %+v", rwy)
+	}
+	if len(rwy.Ends) != 1 || rwy.Ends[0].ID != "18" || rwy.Ends[0].Elevation.Float64 != 464.7 {
+		t.Errorf("runway ends = %+v", rwy.Ends)
+	}
+	if len(a.Contacts) == 0 || len(a.Attendance) == 0 || len(a.Remarks) == 0 {
+		t.Errorf("expected contacts, attendance and remarks; got %d, %d, %d",
+			len(a.Contacts), len(a.Attendance), len(a.Remarks))
+	}
+
+	if _, err := LookupAirport(db, "NOPE"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupAirport(NOPE) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,363 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAirwayGap is returned by Airway.Expand when the airway is not
+// continuous between the entry and exit points.
+var ErrAirwayGap = errors.New("nasr: airway gap")
+
+// Airway is an AWY_BASE airway with its points and AWY_SEG_ALT legs.
+type Airway struct {
+	ID          string // AWY_ID, e.g. "V23" or "J8"
+	Location    string // AWY_LOCATION: "C" contiguous US, "A" Alaska, "H" Hawaii
+	Designation string // AWY_DESIGNATION
+	Regulatory  bool   // REGULATORY
+	Remark      string
+
+	// Points are the airway's points in order, from AIRWAY_STRING.
+	Points []AirwayPoint
+
+	// Legs are the AWY_SEG_ALT segments in POINT_SEQ order. A leg's From
+	// and To are points of the airway; not every pair of adjacent points
+	// necessarily has a leg.
+	Legs []AirwayLeg
+}
+
+// AirwayPoint is a point on an airway resolved against FIX_BASE and NAV_BASE.
+type AirwayPoint struct {
+	Ident    string
+	Type     string // FROM_PT_TYPE, e.g. "WP", "VORTAC"; empty if no leg starts here
+	Region   string // ICAO_REGION_CODE of the resolved fix
+	Lat, Lon float64
+	Resolved bool // false when the ident is in neither FIX_BASE nor NAV_BASE
+}
+
+// IsNavaid reports whether the point's Type names a navaid, such as
+// "VORTAC", rather than a fix.
+func (p AirwayPoint) IsNavaid() bool { return isNavaidType(p.Type) }
+
+// AirwayLeg is one AWY_SEG_ALT segment. Altitudes are in feet MSL; the
+// forward altitudes apply when flying From to To and the opposite ones when
+// flying To to From.
+type AirwayLeg struct {
+	Seq          int // POINT_SEQ
+	From, To     string
+	MagCourse    sql.NullFloat64 // MAG_COURSE, From to To
+	OppMagCourse sql.NullFloat64 // OPP_MAG_COURSE, To to From
+	DistanceNM   sql.NullFloat64 // MAG_COURSE_DIST
+
+	ChangeoverPoint   string          // CHGOVR_PT, the navaid to change over to
+	ChangeoverDistNM  sql.NullFloat64 // CHGOVR_PT_DIST from From
+	Gap               bool            // AWY_SEG_GAP_FLAG, the airway is not flyable From to To
+	SignalGap         bool            // SIGNAL_GAP_FLAG
+	Dogleg            bool            // DOGLEG
+	MEA               Altitude        // MIN_ENROUTE_ALT
+	MEAOpposite       Altitude        // MIN_ENROUTE_ALT_OPPOSITE
+	GPSMEA            Altitude        // GPS_MIN_ENROUTE_ALT
+	GPSMEAOpposite    Altitude        // GPS_MIN_ENROUTE_ALT_OPPOSITE
+	MOCA              sql.NullFloat64 // MIN_OBSTN_CLNC_ALT
+	MaxAuthorizedAlt  sql.NullFloat64 // MAX_AUTH_ALT
+	MinReceptionAlt   sql.NullFloat64 // MIN_RECEP_ALT
+	RequiredNavPerfNM sql.NullFloat64 // REQD_NAV_PERFORMANCE
+}
+
+// Altitude is a minimum altitude that may be restricted to a direction of
+// flight, e.g. 9000 "NE".
+type Altitude struct {
+	Feet      sql.NullFloat64
+	Direction string // compass direction the value applies to, or empty
+}
+
+// MEAFor returns the minimum enroute altitude for flying the leg in the given
+// direction. The opposite-direction MEA is used in reverse when published,
+// otherwise the forward MEA applies both ways.
+func (l AirwayLeg) MEAFor(forward bool) sql.NullFloat64 {
+	if !forward && l.MEAOpposite.Feet.Valid {
+		return l.MEAOpposite.Feet
+	}
+	return l.MEA.Feet
+}
+
+// GPSMEAFor is MEAFor for the GPS MEA.
+func (l AirwayLeg) GPSMEAFor(forward bool) sql.NullFloat64 {
+	if !forward && l.GPSMEAOpposite.Feet.Valid {
+		return l.GPSMEAOpposite.Feet
+	}
+	return l.GPSMEA.Feet
+}
+
+// Leg returns the leg between two adjacent points in either direction, and
+// whether it is flown forward (from a to b in POINT_SEQ order).
+func (a *Airway) Leg(from, to string) (leg *AirwayLeg, forward bool, ok bool) {
+	for i := range a.Legs {
+		l := &a.Legs[i]
+		switch {
+		case l.From == from && l.To == to:
+			return l, true, true
+		case l.From == to && l.To == from:
+			return l, false, true
+		}
+	}
+	return nil, false, false
+}
+
+// index returns the position of ident in Points, or -1.
+func (a *Airway) index(ident string) int {
+	for i, p := range a.Points {
+		if p.Ident == ident {
+			return i
+		}
+	}
+	return -1
+}
+
+// Expand returns the airway's points from entry to exit inclusive, in the
+// direction of flight. Either point may come first on the airway. The error
+// wraps ErrAirwayGap if a leg between them is a gap.
+func (a *Airway) Expand(entry, exit string) ([]AirwayPoint, error) {
+	i, j := a.index(entry), a.index(exit)
+	if i < 0 {
+		return nil, fmt.Errorf("%s is not on airway %s: %w", entry, a.ID, ErrNotFound)
+	}
+	if j < 0 {
+		return nil, fmt.Errorf("%s is not on airway %s: %w", exit, a.ID, ErrNotFound)
+	}
+	var out []AirwayPoint
+	if i <= j {
+		out = append(out, a.Points[i:j+1]...)
+	} else {
+		for k := i; k >= j; k-- {
+			out = append(out, a.Points[k])
+		}
+	}
+	for k := 1; k < len(out); k++ {
+		if leg, _, ok := a.Leg(out[k-1].Ident, out[k].Ident); ok && leg.Gap {
+			return nil, fmt.Errorf("airway %s between %s and %s: %w", a.ID, out[k-1].Ident, out[k].Ident, ErrAirwayGap)
+		}
+	}
+	return out, nil
+}
+
+func airwayLegOf(r record) AirwayLeg {
+	alt := func(col, dirCol string) Altitude {
+		return Altitude{r.float(col), r.str(dirCol)}
+	}
+	return AirwayLeg{
+		Seq:               int(r.float("POINT_SEQ").Float64),
+		From:              r.str("FROM_POINT"),
+		To:                r.str("TO_POINT"),
+		MagCourse:         r.float("MAG_COURSE"),
+		OppMagCourse:      r.float("OPP_MAG_COURSE"),
+		DistanceNM:        r.float("MAG_COURSE_DIST"),
+		ChangeoverPoint:   r.str("CHGOVR_PT"),
+		ChangeoverDistNM:  r.float("CHGOVR_PT_DIST"),
+		Gap:               r.flag("AWY_SEG_GAP_FLAG"),
+		SignalGap:         r.flag("SIGNAL_GAP_FLAG"),
+		Dogleg:            r.flag("DOGLEG"),
+		MEA:               alt("MIN_ENROUTE_ALT", "MIN_ENROUTE_ALT_DIR"),
+		MEAOpposite:       alt("MIN_ENROUTE_ALT_OPPOSITE", "MIN_ENROUTE_ALT_OPPOSITE_DIR"),
+		GPSMEA:            alt("GPS_MIN_ENROUTE_ALT", "GPS_MIN_ENROUTE_ALT_DIR"),
+		GPSMEAOpposite:    alt("GPS_MIN_ENROUTE_ALT_OPPOSITE", "GPS_MEA_OPPOSITE_DIR"),
+		MOCA:              r.float("MIN_OBSTN_CLNC_ALT"),
+		MaxAuthorizedAlt:  r.float("MAX_AUTH_ALT"),
+		MinReceptionAlt:   r.float("MIN_RECEP_ALT"),
+		RequiredNavPerfNM: r.float("REQD_NAV_PERFORMANCE"),
+	}
+}
+
+// loadAirways reads airways matching the given AWY_BASE condition, resolving
+// their points with idx.
+func loadAirways(db *sql.DB, idx *pointIndex, where string, args ...interface{}) ([]Airway, error) {
+	recs, err := queryRecords(db, `SELECT * FROM "AWY_BASE" `+where+` ORDER BY "AWY_ID", "AWY_LOCATION"`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query AWY_BASE: %w", err)
+	}
+	segs, err := queryRecords(db, `SELECT s.* FROM "AWY_SEG_ALT" s
+JOIN (SELECT "AWY_ID", "AWY_LOCATION" FROM "AWY_BASE" `+where+`) b USING ("AWY_ID", "AWY_LOCATION")
+ORDER BY s."AWY_ID", s."AWY_LOCATION", s."POINT_SEQ"`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query AWY_SEG_ALT: %w", err)
+	}
+	type awyKey struct{ id, loc string }
+	segsByAwy := make(map[awyKey][]record)
+	for _, s := range segs {
+		k := awyKey{s.str("AWY_ID"), s.str("AWY_LOCATION")}
+		segsByAwy[k] = append(segsByAwy[k], s)
+	}
+
+	airways := make([]Airway, len(recs))
+	for i, r := range recs {
+		a := &airways[i]
+		*a = Airway{
+			ID:          r.str("AWY_ID"),
+			Location:    r.str("AWY_LOCATION"),
+			Designation: r.str("AWY_DESIGNATION"),
+			Regulatory:  r.flag("REGULATORY"),
+			Remark:      r.str("REMARK"),
+		}
+		fromSeg := make(map[string]record)
+		for _, s := range segsByAwy[awyKey{a.ID, a.Location}] {
+			a.Legs = append(a.Legs, airwayLegOf(s))
+			fromSeg[s.str("FROM_POINT")] = s
+		}
+
+		var prev *namedPoint
+		for _, ident := range strings.Fields(r.str("AIRWAY_STRING")) {
+			p := AirwayPoint{Ident: ident}
+			kind := pointAny
+			if s, ok := fromSeg[ident]; ok {
+				p.Type = s.str("FROM_PT_TYPE")
+				p.Region = s.str("ICAO_REGION_CODE")
+				kind = pointKindOf(p.Type)
+			}
+			if np, ok := idx.resolve(ident, p.Region, kind, prev); ok {
+				p.Lat, p.Lon, p.Resolved = np.lat, np.lon, true
+				if np.region != "" {
+					p.Region = np.region
+				}
+				prev = &np
+			}
+			a.Points = append(a.Points, p)
+		}
+	}
+	return airways, nil
+}
+
+// LookupAirways returns the airways with the given AWY_ID. The same ID can be
+// used in more than one AWY_LOCATION.
+func LookupAirways(db *sql.DB, id string) ([]Airway, error) {
+	idx, err := loadPointIndex(db)
+	if err != nil {
+		return nil, err
+	}
+	return loadAirways(db, idx, `WHERE "AWY_ID" = ?`, id)
+}
+
+// LookupAirway returns the airway with the given AWY_ID and AWY_LOCATION. An
+// empty location matches any; if that leaves several airways the error wraps
+// ErrAmbiguous.
+func LookupAirway(db *sql.DB, id, location string) (*Airway, error) {
+	awys, err := LookupAirways(db, id)
+	if err != nil {
+		return nil, err
+	}
+	var match []Airway
+	for _, a := range awys {
+		if location == "" || a.Location == location {
+			match = append(match, a)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return nil, fmt.Errorf("airway %s: %w", id, ErrNotFound)
+	case 1:
+		return &match[0], nil
+	}
+	return nil, fmt.Errorf("airway %s is defined in %d locations: %w", id, len(match), ErrAmbiguous)
+}
+
+// AirwayGraph is the airway network as a directed graph. Each pair of
+// adjacent airway points contributes an edge in each direction, unless the
+// leg between them is a gap.
+type AirwayGraph struct {
+	Nodes []*AirwayNode
+	byKey map[nodeKey]*AirwayNode
+}
+
+// AirwayNode is a point in the airway network. Points with the same ident
+// and position on different airways share a node.
+type AirwayNode struct {
+	Ident    string
+	Region   string
+	Lat, Lon float64
+	Resolved bool
+	Edges    []*AirwayEdge // outgoing edges
+}
+
+// AirwayEdge is a directed edge between adjacent points of an airway.
+type AirwayEdge struct {
+	From, To   *AirwayNode
+	Airway     string // AWY_ID
+	Location   string // AWY_LOCATION
+	DistanceNM float64
+
+	// Leg is the AWY_SEG_ALT segment between the points, or nil when none is
+	// published. Forward reports whether the edge follows POINT_SEQ order.
+	Leg     *AirwayLeg
+	Forward bool
+}
+
+// MEA returns the minimum enroute altitude in the edge's direction.
+func (e *AirwayEdge) MEA() sql.NullFloat64 {
+	if e.Leg == nil {
+		return sql.NullFloat64{}
+	}
+	return e.Leg.MEAFor(e.Forward)
+}
+
+type nodeKey struct {
+	ident    string
+	lat, lon float64
+}
+
+// Find returns the nodes with the given ident.
+func (g *AirwayGraph) Find(ident string) []*AirwayNode {
+	var out []*AirwayNode
+	for _, n := range g.Nodes {
+		if n.Ident == ident {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (g *AirwayGraph) node(p AirwayPoint) *AirwayNode {
+	k := nodeKey{p.Ident, p.Lat, p.Lon}
+	if n, ok := g.byKey[k]; ok {
+		return n
+	}
+	n := &AirwayNode{Ident: p.Ident, Region: p.Region, Lat: p.Lat, Lon: p.Lon, Resolved: p.Resolved}
+	g.byKey[k] = n
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+// LoadAirwayGraph builds the directed graph of every airway in the database.
+// Nodes are resolved to FIX_BASE or NAV_BASE coordinates; unresolved points
+// are kept with Resolved false and zero edge distances.
+func LoadAirwayGraph(db *sql.DB) (*AirwayGraph, error) {
+	idx, err := loadPointIndex(db)
+	if err != nil {
+		return nil, err
+	}
+	awys, err := loadAirways(db, idx, "")
+	if err != nil {
+		return nil, err
+	}
+	g := &AirwayGraph{byKey: make(map[nodeKey]*AirwayNode)}
+	for i := range awys {
+		a := &awys[i]
+		for j := 1; j < len(a.Points); j++ {
+			p, q := a.Points[j-1], a.Points[j]
+			from, to := g.node(p), g.node(q)
+			leg, fwd, ok := a.Leg(p.Ident, q.Ident)
+			if !ok {
+				leg, fwd = nil, true
+			} else if leg.Gap {
+				continue
+			}
+			var dist float64
+			if p.Resolved && q.Resolved {
+				dist = DistanceNM(p.Lat, p.Lon, q.Lat, q.Lon)
+			}
+			from.Edges = append(from.Edges, &AirwayEdge{from, to, a.ID, a.Location, dist, leg, fwd})
+			to.Edges = append(to.Edges, &AirwayEdge{to, from, a.ID, a.Location, dist, leg, !fwd})
+		}
+	}
+	return g, nil
+}
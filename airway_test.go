@@ -0,0 +1,149 @@
+package nasr
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAirwayLeg_MEAFor(t *testing.T) {
+	leg := AirwayLeg{
+		MEA:         Altitude{sql.NullFloat64{Float64: 9000, Valid: true}, "NE"},
+		MEAOpposite: Altitude{sql.NullFloat64{Float64: 11000, Valid: true}, "SW"},
+	}
+	if got := leg.MEAFor(true).Float64; got != 9000 {
+		t.Errorf("forward MEA = %v, want 9000", got)
+	}
+	if got := leg.MEAFor(false).Float64; got != 11000 {
+		t.Errorf("opposite MEA = %v, want 11000", got)
+	}
+	leg.MEAOpposite = Altitude{}
+	if got := leg.MEAFor(false).Float64; got != 9000 {
+		t.Errorf("opposite MEA without published value = %v, want 9000", got)
+	}
+}
+
+func TestLookupAirway(t *testing.T) {
+	db := openTestDB(t)
+
+	a, err := LookupAirway(db, "A216", "")
+	if err != nil {
+		t.Fatalf("LookupAirway(A216): %v", err)
+	}
+	if len(a.Points) != 7 || a.Points[0].Ident != "MONPI" {
+		t.Fatalf("A216 points = %+v", a.Points)
+	}
+	leg, fwd, ok := a.Leg("OATSS", "MONPI")
+	if !ok || fwd || leg.MEAFor(fwd).Float64 != 18000 {
+		t.Errorf("A216 OATSS-MONPI leg = %+v, forward %v, ok %v", leg, fwd, ok)
+	}
+
+	pts, err := a.Expand("HOOVR", "OATSS")
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	var idents []string
+	for _, p := range pts {
+		idents = append(idents, p.Ident)
+	}
+	if want := []string{"HOOVR", "LOEBB", "RIDLL", "OATSS"}; !reflect.DeepEqual(idents, want) {
+		t.Errorf("Expand(HOOVR, OATSS) = %v, want %v", idents, want)
+	}
+	if _, err := a.Expand("HOOVR", "NOPE"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expand to a point not on the airway: %v", err)
+	}
+
+	if _, err := LookupAirway(db, "A216", "A"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupAirway(A216, Alaska) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLoadAirwayGraph(t *testing.T) {
+	db := openTestDB(t)
+
+	g, err := LoadAirwayGraph(db)
+	if err != nil {
+		t.Fatalf("LoadAirwayGraph: %v", err)
+	}
+	nodes := g.Find("OATSS")
+	if len(nodes) != 1 {
+		t.Fatalf("Find(OATSS) = %d nodes, want 1", len(nodes))
+	}
+	var toMONPI *AirwayEdge
+	for _, e := range nodes[0].Edges {
+		if e.To.Ident == "MONPI" {
+			toMONPI = e
+		}
+	}
+	if toMONPI == nil || toMONPI.Airway != "A216" || toMONPI.Forward || toMONPI.MEA().Float64 != 18000 {
+		t.Errorf("OATSS->MONPI edge = %+v", toMONPI)
+	}
+	for _, n := range g.Nodes {
+		for _, e := range n.Edges {
+			if e.From != n {
+				t.Fatalf("edge %s->%s listed under %s", e.From.Ident, e.To.Ident, n.Ident)
+			}
+		}
+	}
+}
+
+func TestAirwayGap(t *testing.T) {
+	// The test subscription has no gaps; make A216's MONPI-OATSS leg one.
+	src := writeSubscription(t, "19_Feb_2026_CSV.zip", func(name string, data []byte) []byte {
+		if name == "AWY_SEG_ALT.csv" {
+			data = bytes.Replace(data, []byte(",A216,10,MONPI,WP   ,,,ZAK,P,OP,US,OATSS,163.32,342.18,269.2,,,,N,"),
+				[]byte(",A216,10,MONPI,WP   ,,,ZAK,P,OP,US,OATSS,163.32,342.18,269.2,,,,Y,"), 1)
+		}
+		return data
+	})
+	path := filepath.Join(t.TempDir(), "gap.db")
+	if err := Extract(src, path); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	a, err := LookupAirway(db, "A216", "")
+	if err != nil {
+		t.Fatalf("LookupAirway(A216): %v", err)
+	}
+	if leg, _, ok := a.Leg("MONPI", "OATSS"); !ok || !leg.Gap {
+		t.Fatalf("A216 MONPI-OATSS leg = %+v, want a gap", leg)
+	}
+	if _, err := a.Expand("RIDLL", "MONPI"); !errors.Is(err, ErrAirwayGap) {
+		t.Errorf("Expand across the gap error = %v, want ErrAirwayGap", err)
+	}
+	if _, err := a.Expand("HOOVR", "OATSS"); err != nil {
+		t.Errorf("Expand short of the gap: %v", err)
+	}
+
+	g, err := LoadAirwayGraph(db)
+	if err != nil {
+		t.Fatalf("LoadAirwayGraph: %v", err)
+	}
+	for _, n := range g.Find("OATSS") {
+		var toRIDLL bool
+		for _, e := range n.Edges {
+			if e.To.Ident == "MONPI" {
+				t.Errorf("graph has an edge across the gap: %+v", e)
+			}
+			toRIDLL = toRIDLL || e.To.Ident == "RIDLL"
+		}
+		if !toRIDLL {
+			t.Errorf("OATSS lost its edge to RIDLL")
+		}
+	}
+	for _, n := range g.Find("MONPI") {
+		for _, e := range n.Edges {
+			if e.Airway == "A216" {
+				t.Errorf("graph has an edge across the gap: %+v", e)
+			}
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// arbPolygonSchema is the derived ARB_POLYGON table: one row per vertex of
+// the closed boundary rings assembled from ARB_SEG. RING_NO 0 is the outer
+// ring of a polygon and higher numbers are holes. A center and stratum
+// (LOCATION_ID, ALTITUDE, TYPE) may consist of several polygons.
+var arbPolygonSchema = &tableSchema{
+	name: "ARB_POLYGON",
+	columns: []columnDef{
+		{name: "LOCATION_ID", dataType: "TEXT"},
+		{name: "LOCATION_NAME", dataType: "TEXT", nullable: true},
+		{name: "ALTITUDE", dataType: "TEXT", nullable: true},
+		{name: "TYPE", dataType: "TEXT", nullable: true},
+		{name: "POLYGON_NO", dataType: "REAL"},
+		{name: "RING_NO", dataType: "REAL"},
+		{name: "POINT_NO", dataType: "REAL"},
+		{name: "LAT_DECIMAL", dataType: "REAL"},
+		{name: "LONG_DECIMAL", dataType: "REAL"},
+	},
+}
+
+// arbPoint is one ARB_SEG boundary point.
+type arbPoint struct {
+	lat, lon float64
+	descrip  string
+}
+
+// assembleRings splits an ordered list of boundary points into closed rings.
+// A ring ends at a point whose description reads "POINT OF BEGINNING", or
+// when the boundary returns to the ring's first point. Rings with fewer than
+// three distinct points are dropped.
+func assembleRings(pts []arbPoint) [][]coord {
+	var rings [][]coord
+	var cur []coord
+	closeRing := func() {
+		if len(cur) >= 3 {
+			if cur[0] != cur[len(cur)-1] {
+				cur = append(cur, cur[0])
+			}
+			rings = append(rings, cur)
+		}
+		cur = nil
+	}
+	for _, p := range pts {
+		c := coord{p.lon, p.lat}
+		if len(cur) >= 3 && c == cur[0] {
+			closeRing()
+			continue
+		}
+		cur = append(cur, c)
+		if strings.Contains(strings.ToUpper(p.descrip), "POINT OF BEGINNING") {
+			closeRing()
+		}
+	}
+	closeRing()
+	return rings
+}
+
+// groupPolygons arranges rings into polygons. Rings are taken largest first;
+// a ring inside an existing polygon (and not inside one of its holes) becomes
+// a hole of that polygon, otherwise it starts a new polygon.
+func groupPolygons(rings [][]coord) [][][]coord {
+	sorted := append([][]coord(nil), rings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return ringArea(sorted[i]) > ringArea(sorted[j]) })
+
+	var polys [][][]coord
+	for _, r := range sorted {
+		placed := false
+		for i, p := range polys {
+			if !ringContains(p[0], r[0].lat, r[0].lon) {
+				continue
+			}
+			inHole := false
+			for _, h := range p[1:] {
+				if ringContains(h, r[0].lat, r[0].lon) {
+					inHole = true
+					break
+				}
+			}
+			if !inHole {
+				polys[i] = append(polys[i], r)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			polys = append(polys, [][]coord{r})
+		}
+	}
+	return polys
+}
+
+// unwrapRing returns the ring with longitudes made continuous across the
+// antimeridian, so that no edge spans more than 180 degrees.
+func unwrapRing(ring []coord) []coord {
+	out := make([]coord, len(ring))
+	copy(out, ring)
+	for i := 1; i < len(out); i++ {
+		for out[i].lon-out[i-1].lon > 180 {
+			out[i].lon -= 360
+		}
+		for out[i].lon-out[i-1].lon < -180 {
+			out[i].lon += 360
+		}
+	}
+	return out
+}
+
+// ringArea returns the absolute planar area of a ring in square degrees.
+func ringArea(ring []coord) float64 {
+	r := unwrapRing(ring)
+	var a float64
+	for i := 0; i+1 < len(r); i++ {
+		a += r[i].lon*r[i+1].lat - r[i+1].lon*r[i].lat
+	}
+	return math.Abs(a) / 2
+}
+
+// ringContains reports whether a point lies inside a closed ring, using ray
+// casting on unwrapped longitudes.
+func ringContains(ring []coord, lat, lon float64) bool {
+	r := unwrapRing(ring)
+	for _, x := range []float64{lon, lon - 360, lon + 360} {
+		inside := false
+		for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+			if (r[i].lat > lat) != (r[j].lat > lat) &&
+				x < (r[j].lon-r[i].lon)*(lat-r[i].lat)/(r[j].lat-r[i].lat)+r[i].lon {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
+
+// buildARBPolygons assembles ARB_SEG boundary points into closed rings per
+// center and stratum and stores their vertices in ARB_POLYGON.
+func buildARBPolygons(db *sql.DB) error {
+	rows, err := db.Query(`SELECT "LOCATION_ID", "LOCATION_NAME", "ALTITUDE", "TYPE",
+  "LAT_DECIMAL", "LONG_DECIMAL", "BNDRY_PT_DESCRIP"
+FROM "ARB_SEG"
+WHERE typeof("LAT_DECIMAL") = 'real' AND typeof("LONG_DECIMAL") = 'real'
+ORDER BY "LOCATION_ID", "ALTITUDE", "TYPE", "POINT_SEQ"`)
+	if err != nil {
+		return fmt.Errorf("query ARB_SEG: %w", err)
+	}
+	type stratum struct{ id, name, alt, typ string }
+	var order []stratum
+	points := make(map[stratum][]arbPoint)
+	for rows.Next() {
+		var id string
+		var name, alt, typ, descrip sql.NullString
+		var p arbPoint
+		if err := rows.Scan(&id, &name, &alt, &typ, &p.lat, &p.lon, &descrip); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan ARB_SEG: %w", err)
+		}
+		p.descrip = descrip.String
+		s := stratum{id, name.String, alt.String, typ.String}
+		if _, ok := points[s]; !ok {
+			order = append(order, s)
+		}
+		points[s] = append(points[s], p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ARB_SEG iteration: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO "ARB_POLYGON" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	nullable := func(s string) interface{} {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	for _, s := range order {
+		polys := groupPolygons(assembleRings(points[s]))
+		for pi, poly := range polys {
+			for ri, ring := range poly {
+				for ci, c := range ring {
+					_, err := stmt.Exec(s.id, nullable(s.name), nullable(s.alt), nullable(s.typ),
+						pi+1, ri, ci+1, c.lat, c.lon)
+					if err != nil {
+						return fmt.Errorf("insert ARB_POLYGON: %w", err)
+					}
+				}
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// ARTCCBoundary identifies one ARTCC boundary stratum.
+type ARTCCBoundary struct {
+	LocationID   string // e.g. "ZLC"
+	LocationName string
+	Altitude     string // e.g. "HIGH" or "LOW"
+	Type         string // e.g. "ARTCC" or "FIR"
+}
+
+// arbPolygon is one assembled polygon read back from ARB_POLYGON.
+type arbPolygon struct {
+	boundary ARTCCBoundary
+	rings    [][]coord
+}
+
+func loadARBPolygons(db *sql.DB) ([]arbPolygon, error) {
+	rows, err := db.Query(`SELECT "LOCATION_ID", "LOCATION_NAME", "ALTITUDE", "TYPE",
+  "POLYGON_NO", "RING_NO", "LAT_DECIMAL", "LONG_DECIMAL"
+FROM "ARB_POLYGON"
+ORDER BY "LOCATION_ID", "ALTITUDE", "TYPE", "POLYGON_NO", "RING_NO", "POINT_NO"`)
+	if err != nil {
+		return nil, fmt.Errorf("query ARB_POLYGON: %w", err)
+	}
+	defer rows.Close()
+
+	var polys []arbPolygon
+	var lastPoly, lastRing int
+	for rows.Next() {
+		var b ARTCCBoundary
+		var name, alt, typ sql.NullString
+		var polyNo, ringNo int
+		var lat, lon float64
+		if err := rows.Scan(&b.LocationID, &name, &alt, &typ, &polyNo, &ringNo, &lat, &lon); err != nil {
+			return nil, fmt.Errorf("scan ARB_POLYGON: %w", err)
+		}
+		b.LocationName, b.Altitude, b.Type = name.String, alt.String, typ.String
+		n := len(polys)
+		if n == 0 || polys[n-1].boundary != b || polyNo != lastPoly {
+			polys = append(polys, arbPolygon{boundary: b})
+			n++
+			lastRing = -1
+		}
+		p := &polys[n-1]
+		if ringNo != lastRing {
+			p.rings = append(p.rings, nil)
+		}
+		p.rings[len(p.rings)-1] = append(p.rings[len(p.rings)-1], coord{lon, lat})
+		lastPoly, lastRing = polyNo, ringNo
+	}
+	return polys, rows.Err()
+}
+
+// ARTCCAt returns every ARTCC boundary stratum whose assembled polygon in
+// ARB_POLYGON contains the point.
+func ARTCCAt(db *sql.DB, lat, lon float64) ([]ARTCCBoundary, error) {
+	polys, err := loadARBPolygons(db)
+	if err != nil {
+		return nil, err
+	}
+	var out []ARTCCBoundary
+	for _, p := range polys {
+		if !ringContains(p.rings[0], lat, lon) {
+			continue
+		}
+		inHole := false
+		for _, h := range p.rings[1:] {
+			if ringContains(h, lat, lon) {
+				inHole = true
+				break
+			}
+		}
+		if inHole {
+			continue
+		}
+		dup := false
+		for _, b := range out {
+			if b == p.boundary {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, p.boundary)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package nasr
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssembleRings(t *testing.T) {
+	pts := []arbPoint{
+		{40, -110, ""},
+		{40, -100, ""},
+		{30, -100, ""},
+		{30, -110, "TO POINT OF BEGINNING"},
+		{20, -90, ""},
+		{20, -80, ""},
+		{10, -80, ""},
+		{20, -90, ""}, // returns to first point
+		{0, 0, ""},    // dangling, dropped
+	}
+	rings := assembleRings(pts)
+	if len(rings) != 2 {
+		t.Fatalf("expected 2 rings, got %d: %v", len(rings), rings)
+	}
+	for i, r := range rings {
+		if r[0] != r[len(r)-1] {
+			t.Errorf("ring %d not closed: %v", i, r)
+		}
+	}
+	if len(rings[0]) != 5 || len(rings[1]) != 4 {
+		t.Errorf("ring sizes = %d, %d; want 5, 4", len(rings[0]), len(rings[1]))
+	}
+}
+
+func TestGroupPolygons(t *testing.T) {
+	outer := []coord{{-110, 30}, {-100, 30}, {-100, 40}, {-110, 40}, {-110, 30}}
+	hole := []coord{{-106, 34}, {-104, 34}, {-104, 36}, {-106, 36}, {-106, 34}}
+	other := []coord{{10, 10}, {11, 10}, {11, 11}, {10, 10}}
+	polys := groupPolygons([][]coord{hole, other, outer})
+	if len(polys) != 2 {
+		t.Fatalf("expected 2 polygons, got %d", len(polys))
+	}
+	if len(polys[0]) != 2 || polys[0][0][0] != outer[0] {
+		t.Errorf("expected outer ring with one hole first, got %v", polys[0])
+	}
+}
+
+func TestRingContains_Antimeridian(t *testing.T) {
+	ring := []coord{{170, 50}, {-170, 50}, {-170, 60}, {170, 60}, {170, 50}}
+	if !ringContains(ring, 55, 179.5) || !ringContains(ring, 55, -179.5) {
+		t.Error("expected points either side of the antimeridian to be inside")
+	}
+	if ringContains(ring, 55, 0) {
+		t.Error("expected point at 0 lon to be outside")
+	}
+}
+
+func TestARTCCAt(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	createTables, _ := generateDDL(map[string]*tableSchema{"ARB_POLYGON": arbPolygonSchema}, nil)
+	stmts := append(createTables,
+		`CREATE TABLE ARB_SEG (LOCATION_ID TEXT, LOCATION_NAME TEXT, ALTITUDE TEXT, TYPE TEXT,
+  POINT_SEQ REAL, LAT_DECIMAL REAL, LONG_DECIMAL REAL, BNDRY_PT_DESCRIP TEXT)`,
+		`INSERT INTO ARB_SEG VALUES
+  ('ZLC', 'SALT LAKE CITY', 'HIGH', 'ARTCC', 10, 46, -118, ''),
+  ('ZLC', 'SALT LAKE CITY', 'HIGH', 'ARTCC', 20, 46, -108, ''),
+  ('ZLC', 'SALT LAKE CITY', 'HIGH', 'ARTCC', 30, 38, -108, ''),
+  ('ZLC', 'SALT LAKE CITY', 'HIGH', 'ARTCC', 40, 38, -118, 'TO POINT OF BEGINNING'),
+  ('ZSE', 'SEATTLE', 'HIGH', 'ARTCC', 10, 49, -125, ''),
+  ('ZSE', 'SEATTLE', 'HIGH', 'ARTCC', 20, 49, -116, ''),
+  ('ZSE', 'SEATTLE', 'HIGH', 'ARTCC', 30, 46, -116, ''),
+  ('ZSE', 'SEATTLE', 'HIGH', 'ARTCC', 40, 46, -125, 'TO POINT OF BEGINNING')`,
+	)
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("exec: %v\n%s", err, s)
+		}
+	}
+	if err := buildARBPolygons(db); err != nil {
+		t.Fatalf("buildARBPolygons: %v", err)
+	}
+
+	got, err := ARTCCAt(db, 43.56, -116.22) // KBOI
+	if err != nil {
+		t.Fatalf("ARTCCAt: %v", err)
+	}
+	if len(got) != 1 || got[0].LocationID != "ZLC" || got[0].Altitude != "HIGH" {
+		t.Errorf("ARTCCAt(KBOI) = %+v, want ZLC HIGH", got)
+	}
+	if got, _ := ARTCCAt(db, 20, -150); len(got) != 0 {
+		t.Errorf("ARTCCAt outside all boundaries = %+v", got)
+	}
+}
+
+func TestExtract_ARBPolygonTable(t *testing.T) {
+	// The test subscription has no ARB_SEG rows; add a ZLC high stratum.
+	src := writeSubscription(t, "19_Feb_2026_CSV.zip", func(name string, data []byte) []byte {
+		switch name {
+		case "ARB_BASE.csv":
+			data = append(data, "2026/02/19,ZLC,SALT LAKE CITY,ZLC,KZLC,ARTCC,SALT LAKE CITY,UT,US,40,47,0,N,40.78333333,111,57,0,W,-111.95,\n"...)
+		case "ARB_SEG.csv":
+			data = append(data, strings.Join([]string{
+				"2026/02/19,ZLC*H*1,ZLC,SALT LAKE CITY,HIGH,ARTCC,10,46,0,0,N,46,118,0,0,W,-118,,",
+				"2026/02/19,ZLC*H*2,ZLC,SALT LAKE CITY,HIGH,ARTCC,20,46,0,0,N,46,108,0,0,W,-108,,",
+				"2026/02/19,ZLC*H*3,ZLC,SALT LAKE CITY,HIGH,ARTCC,30,38,0,0,N,38,108,0,0,W,-108,,",
+				"2026/02/19,ZLC*H*4,ZLC,SALT LAKE CITY,HIGH,ARTCC,40,38,0,0,N,38,118,0,0,W,-118,TO POINT OF BEGINNING,",
+			}, "\n")+"\n"...)
+		}
+		return data
+	})
+	path := filepath.Join(t.TempDir(), "arb.db")
+	if err := Extract(src, path); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM ARB_POLYGON WHERE LOCATION_ID = 'ZLC' AND ALTITUDE = 'HIGH' AND RING_NO = 0`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("ARB_POLYGON has %d ZLC ring points, want 5", n)
+	}
+	got, err := ARTCCAt(db, 43.56, -116.22) // KBOI
+	if err != nil {
+		t.Fatalf("ARTCCAt: %v", err)
+	}
+	if len(got) != 1 || got[0].LocationID != "ZLC" || got[0].Altitude != "HIGH" {
+		t.Errorf("ARTCCAt(KBOI) = %+v, want ZLC HIGH", got)
+	}
+}
@@ -1,19 +1,221 @@
 package main
 
 import (
+	"database/sql"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	nasr "github.com/IdahoAvionics/go-nasr"
 )
 
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Fprintf(os.Stderr, "usage: %s <nasr-subscription.zip> <output.db>\n", os.Args[0])
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		ok, err := runVerify(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "append" {
+		if err := runAppend(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := runDiff(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fts := flag.Bool("fts", false, "build a full-text search index (NASR_SEARCH)")
+	rtree := flag.Bool("rtree", false, "build R*Tree spatial indexes (<TABLE>_RTREE)")
+	gpkg := flag.Bool("gpkg", false, "write an OGC GeoPackage with feature layers")
+	strict := flag.Bool("strict", false, "fail when a CSV header does not match its structure file")
+	nullUnparsed := flag.Bool("null-unparsed", false, "store NULL for values of numeric columns that are not numbers")
+	badRows := flag.String("bad-rows", "fail", "what to do with a CSV record that cannot be loaded: fail, skip or reject (to NASR_REJECT)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] <nasr-subscription.zip> <output.db>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s export geojson -layer <layer> <nasr.db> [output.geojson]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s verify <nasr-subscription.zip>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s append <nasr-subscription.zip> <history.db>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s diff [-json] [-table] [-summary airport|state|artcc] <old.db> <new.db>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
 		os.Exit(1)
 	}
-	if err := nasr.Extract(os.Args[1], os.Args[2]); err != nil {
+
+	var opts []nasr.Option
+	if *fts {
+		opts = append(opts, nasr.WithFullTextSearch())
+	}
+	if *rtree {
+		opts = append(opts, nasr.WithSpatialIndex())
+	}
+	if *gpkg {
+		opts = append(opts, nasr.WithGeoPackage())
+	}
+	if *strict {
+		opts = append(opts, nasr.WithStrictColumns())
+	}
+	if *nullUnparsed {
+		opts = append(opts, nasr.WithNullOnParseFailure())
+	}
+	switch *badRows {
+	case "fail":
+	case "skip":
+		opts = append(opts, nasr.WithRowErrors(nasr.SkipRowErrors))
+	case "reject":
+		opts = append(opts, nasr.WithRowErrors(nasr.RejectRowErrors))
+	default:
+		fmt.Fprintf(os.Stderr, "error: -bad-rows must be fail, skip or reject\n")
+		os.Exit(1)
+	}
+	if err := nasr.Extract(flag.Arg(0), flag.Arg(1), opts...); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// runExport handles "nasr export geojson". Output goes to stdout unless an
+// output path is given.
+func runExport(args []string) error {
+	if len(args) == 0 || args[0] != "geojson" {
+		return fmt.Errorf("usage: nasr export geojson -layer <layer> <nasr.db> [output.geojson]")
+	}
+	fs := flag.NewFlagSet("export geojson", flag.ContinueOnError)
+	layer := fs.String("layer", "", "layer to export: "+strings.Join(nasr.GeoJSONLayers(), ", "))
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *layer == "" || fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("usage: nasr export geojson -layer <layer> <nasr.db> [output.geojson]")
+	}
+
+	if _, err := os.Stat(fs.Arg(0)); err != nil {
+		return fmt.Errorf("input database: %w", err)
+	}
+	db, err := sql.Open("sqlite", fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+
+	if fs.NArg() == 1 {
+		return nasr.ExportGeoJSON(db, os.Stdout, *layer)
+	}
+	f, err := os.Create(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("create output: %w", err)
+	}
+	err = nasr.ExportGeoJSON(db, f, *layer)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("write output: %w", cerr)
+	}
+	if err != nil {
+		// Do not leave a truncated file behind.
+		os.Remove(fs.Arg(1))
+	}
+	return err
+}
+
+// runDiff handles "nasr diff". The report, or JSON with -json, goes to
+// stdout; -table also records the changes in new.db's CHANGES table. With
+// -summary the output is the Markdown (or JSON) change summary instead.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "write the changes as JSON")
+	table := fs.Bool("table", false, "append the changes to a CHANGES table in <new.db>")
+	summary := fs.String("summary", "", "write a change summary grouped by airport, state or artcc")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: nasr diff [-json] [-table] [-summary airport|state|artcc] <old.db> <new.db>")
+	}
+
+	var dbs [2]*sql.DB
+	for i, path := range fs.Args() {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("input database: %w", err)
+		}
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer db.Close()
+		dbs[i] = db
+	}
+
+	cs, err := nasr.Diff(dbs[0], dbs[1])
+	if err != nil {
+		return err
+	}
+	if *table {
+		if err := cs.WriteTable(dbs[1]); err != nil {
+			return err
+		}
+	}
+	if *summary != "" {
+		by := nasr.GroupBy(*summary)
+		if by != nasr.GroupByAirport && by != nasr.GroupByState && by != nasr.GroupByARTCC {
+			return fmt.Errorf("-summary: want airport, state or artcc, got %q", *summary)
+		}
+		sum, err := nasr.Summarize(dbs[0], dbs[1], cs)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return sum.WriteJSON(os.Stdout, by)
+		}
+		return sum.WriteMarkdown(os.Stdout, by)
+	}
+	if *asJSON {
+		return cs.WriteJSON(os.Stdout)
+	}
+	return cs.WriteReport(os.Stdout)
+}
+
+// runVerify handles "nasr verify", writing the report to stdout. ok is false
+// when problems were found.
+func runVerify(args []string) (ok bool, err error) {
+	if len(args) != 1 {
+		return false, fmt.Errorf("usage: nasr verify <nasr-subscription.zip>")
+	}
+	r, err := nasr.Verify(args[0])
+	if err != nil {
+		return false, err
+	}
+	if err := r.WriteText(os.Stdout); err != nil {
+		return false, err
+	}
+	return r.OK(), nil
+}
+
+// runAppend handles "nasr append", loading a subscription into a history
+// database.
+func runAppend(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: nasr append <nasr-subscription.zip> <history.db>")
+	}
+	return nasr.Append(args[0], args[1])
+}
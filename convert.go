@@ -0,0 +1,148 @@
+package nasr
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// A Parser converts a column's non-empty data file values to the values
+// stored in the database. Register one with WithParser. A value it returns
+// an error for is counted in the Report's Conversions and stored as text,
+// or as NULL under WithNullOnParseFailure.
+type Parser func(string) (interface{}, error)
+
+// ParseElevation parses a number of feet that may have thousands
+// separators or a unit, e.g. "1,234", "1234 FT" or "-12.5ft".
+func ParseElevation(s string) (interface{}, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	for _, unit := range []string{"FEET", "FT"} {
+		if strings.HasSuffix(v, unit) {
+			v = strings.TrimSpace(strings.TrimSuffix(v, unit))
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+	if err != nil {
+		return nil, fmt.Errorf("not an elevation: %q", s)
+	}
+	return f, nil
+}
+
+// ZeroPad returns a Parser that pads the leading digits of a value with
+// zeros to width, so that "9" and "09", or "9L" and "09L", are stored
+// alike. It is meant for TEXT identifiers such as runway end IDs; a REAL
+// column would read "09" as 9. Values that do not start with a digit, like
+// helipad "H1", are rejected.
+func ZeroPad(width int) Parser {
+	return func(s string) (interface{}, error) {
+		n := len(s) - len(strings.TrimLeft(s, "0123456789"))
+		if n == 0 {
+			return nil, errors.New("no leading digits")
+		}
+		if n < width {
+			s = strings.Repeat("0", width-n) + s
+		}
+		return s, nil
+	}
+}
+
+// maxConversionSamples limits the failed values kept per column.
+const maxConversionSamples = 5
+
+// converter converts the values of one data file, applying registered
+// parsers and tallying the values that fail to parse.
+type converter struct {
+	schema        *tableSchema
+	rules         [][]Rule // by column index
+	parsers       []Parser // by column index
+	nullOnFailure bool
+	failures      []*ConversionFailure
+	trimmed       []int // by column index
+}
+
+func newConverter(schema *tableSchema, cfg *config) *converter {
+	c := &converter{
+		schema:        schema,
+		rules:         make([][]Rule, len(schema.columns)),
+		parsers:       make([]Parser, len(schema.columns)),
+		nullOnFailure: cfg.nullOnParseFailure,
+		failures:      make([]*ConversionFailure, len(schema.columns)),
+		trimmed:       make([]int, len(schema.columns)),
+	}
+	for i, col := range schema.columns {
+		c.rules[i] = columnRules(cfg.rules, schema.name, col.name)
+		c.parsers[i] = cfg.parsers[[2]string{schema.name, col.name}]
+	}
+	return c
+}
+
+// convert trims the value if column i is trimmed, applies its rules and
+// converts it. Without a parser, only a REAL column's values can fail, when
+// strconv.ParseFloat rejects them. Failed values of NOT NULL columns are
+// kept as text.
+func (c *converter) convert(i int, val string) interface{} {
+	col := c.schema.columns[i]
+	if col.trim {
+		if t := strings.TrimSpace(val); t != val {
+			c.trimmed[i]++
+			val = t
+		}
+	}
+	val, null := applyRules(val, c.rules[i])
+	if null {
+		return nil
+	}
+	v := convertValue(val, col)
+	if v == nil || val == "" {
+		return v
+	}
+	if p := c.parsers[i]; p != nil {
+		pv, err := p(val)
+		if err == nil {
+			return pv
+		}
+		v = val
+	} else if _, text := v.(string); !text || col.dataType != "REAL" {
+		return v
+	}
+
+	f := c.failures[i]
+	if f == nil {
+		f = &ConversionFailure{Table: c.schema.name, Column: col.name}
+		c.failures[i] = f
+	}
+	f.Count++
+	if len(f.Samples) < maxConversionSamples && !containsString(f.Samples, val) {
+		f.Samples = append(f.Samples, val)
+	}
+	if c.nullOnFailure && col.nullable {
+		return nil
+	}
+	return v
+}
+
+// finish logs the failures and adds them and the trim counts to the
+// report.
+func (c *converter) finish(report *Report) {
+	for i, col := range c.schema.columns {
+		if col.trim && report != nil {
+			report.Trimmed = append(report.Trimmed, TrimmedColumn{c.schema.name, col.name, c.trimmed[i]})
+		}
+	}
+	for i, f := range c.failures {
+		if f == nil {
+			continue
+		}
+		stored := "text"
+		if c.nullOnFailure && c.schema.columns[i].nullable {
+			stored = "NULL"
+		}
+		log.Printf("WARNING: %s.%s: %d values did not parse (e.g. %q); stored as %s", f.Table, f.Column, f.Count, f.Samples[0], stored)
+		if report != nil {
+			report.Conversions = append(report.Conversions, *f)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+
+	"github.com/IdahoAvionics/go-nasr/cycle"
+)
+
+// effDateLayout is the format of the EFF_DATE column, e.g. "2026/02/19".
+const effDateLayout = "2006/01/02"
+
+// nasrCycleSchema is the derived NASR_CYCLE table recording the AIRAC cycle
+// the database was extracted from. It has one row, or none when the cycle
+// could not be determined from the subscription's file names.
+var nasrCycleSchema = &tableSchema{
+	name: "NASR_CYCLE",
+	columns: []columnDef{
+		{name: "CYCLE", dataType: "TEXT"},           // e.g. "2602"
+		{name: "EFFECTIVE_DATE", dataType: "TEXT"},  // EFF_DATE format, e.g. "2026/02/19"
+		{name: "EXPIRATION_DATE", dataType: "TEXT"}, // effective date of the next cycle
+		{name: "SOURCE", dataType: "TEXT"},          // inner CSV zip name
+	},
+}
+
+// subscriptionCycle determines the cycle of a subscription from the inner
+// CSV zip name (CSV_Data/19_Feb_2026_CSV.zip) and, when it carries a date,
+// the outer file name. It fails if either date is not an AIRAC effective
+// date or the two disagree. ok is false when the inner name has no date.
+func subscriptionCycle(outerPath, innerName string) (c cycle.Cycle, ok bool, err error) {
+	c, err = cycle.ParseFilename(filepath.Base(innerName))
+	if errors.Is(err, cycle.ErrNotEffectiveDate) {
+		return c, false, fmt.Errorf("%s: %w", innerName, err)
+	}
+	if err != nil {
+		log.Printf("WARNING: cannot determine the AIRAC cycle: %v", err)
+		return c, false, nil
+	}
+	outer, err := cycle.ParseFilename(filepath.Base(outerPath))
+	switch {
+	case errors.Is(err, cycle.ErrNotEffectiveDate):
+		return c, false, fmt.Errorf("%s: %w", outerPath, err)
+	case err == nil && outer != c:
+		return c, false, fmt.Errorf("%s is cycle %s but contains %s for cycle %s", outerPath, outer, innerName, c)
+	}
+	return c, true, nil
+}
+
+// checkEffectiveDates warns about tables whose EFF_DATE is later than the
+// cycle's effective date. Earlier dates are normal: groups that did not
+// change keep the date of the cycle they were last published in.
+func checkEffectiveDates(db *sql.DB, tables map[string]*tableSchema, c cycle.Cycle) error {
+	eff := c.Effective().Format(effDateLayout)
+	names := make([]string, 0, len(tables))
+	for name, ts := range tables {
+		for _, col := range ts.columns {
+			if col.name == "EFF_DATE" {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var max sql.NullString
+		if err := db.QueryRow(fmt.Sprintf(`SELECT max("EFF_DATE") FROM %q`, name)).Scan(&max); err != nil {
+			return fmt.Errorf("EFF_DATE of %s: %w", name, err)
+		}
+		if max.Valid && max.String > eff {
+			log.Printf("WARNING: %s has EFF_DATE %s, after the cycle %s effective date %s", name, max.String, c, eff)
+		}
+	}
+	return nil
+}
+
+// recordCycle writes the cycle to NASR_CYCLE.
+func recordCycle(db *sql.DB, c cycle.Cycle, source string) error {
+	_, err := db.Exec(`INSERT INTO "NASR_CYCLE" VALUES (?, ?, ?, ?)`,
+		c.ID(), c.Effective().Format(effDateLayout), c.Expires().Format(effDateLayout), source)
+	return err
+}
+
+// DatabaseCycle returns the AIRAC cycle recorded in a database by Extract,
+// or the latest cycle of a history database (see Append). It returns
+// ErrNotFound when no cycle was recorded.
+func DatabaseCycle(db *sql.DB) (cycle.Cycle, error) {
+	var id string
+	err := db.QueryRow(`SELECT "CYCLE" FROM "NASR_CYCLE" ORDER BY "CYCLE" DESC LIMIT 1`).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cycle.Cycle{}, fmt.Errorf("database cycle: %w", ErrNotFound)
+	}
+	if err != nil {
+		return cycle.Cycle{}, fmt.Errorf("database cycle: %w", err)
+	}
+	return cycle.Parse(id)
+}
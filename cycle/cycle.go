@@ -0,0 +1,174 @@
+// Package cycle implements the AIRAC 28-day calendar on which FAA NASR
+// subscriptions are published.
+//
+// A cycle is identified by the last two digits of the year of its effective
+// date and its number within that year, e.g. "2602" for the second cycle of
+// 2026, effective 19 February 2026. A cycle is in effect until the next
+// one's effective date, 28 days later.
+package cycle
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Length is the duration of a cycle.
+const Length = 28 * 24 * time.Hour
+
+// epoch is the effective date of cycle 2001. Every effective date is a whole
+// number of cycles from it.
+var epoch = time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+// ErrNotEffectiveDate is returned when a date that should start a cycle does
+// not fall on the 28-day schedule.
+var ErrNotEffectiveDate = errors.New("cycle: not an AIRAC effective date")
+
+// Cycle is one AIRAC cycle.
+type Cycle struct {
+	Year   int // four-digit year of the effective date
+	Number int // 1-based number of the cycle within Year
+}
+
+// At returns the cycle in effect at t. Cycles change at 00:00 UTC on the
+// effective date.
+func At(t time.Time) Cycle {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	k := floorDiv(int(day.Sub(epoch).Hours()/24), 28)
+	eff := epoch.AddDate(0, 0, 28*k)
+	first := firstOfYear(eff.Year())
+	return Cycle{Year: eff.Year(), Number: int(eff.Sub(first).Hours()/24)/28 + 1}
+}
+
+// FromEffective returns the cycle that starts on the given date. The error
+// wraps ErrNotEffectiveDate when no cycle starts then.
+func FromEffective(date time.Time) (Cycle, error) {
+	c := At(date)
+	if !sameDay(c.Effective(), date) {
+		return Cycle{}, fmt.Errorf("%s: %w", date.Format("2006-01-02"), ErrNotEffectiveDate)
+	}
+	return c, nil
+}
+
+// Parse parses a four-digit cycle identifier such as "2602".
+func Parse(id string) (Cycle, error) {
+	if len(id) != 4 {
+		return Cycle{}, fmt.Errorf("cycle %q: want four digits", id)
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 0 {
+		return Cycle{}, fmt.Errorf("cycle %q: want four digits", id)
+	}
+	c := Cycle{Year: 2000 + n/100, Number: n % 100}
+	if c.Number < 1 || c.Number > cyclesIn(c.Year) {
+		return Cycle{}, fmt.Errorf("cycle %q: %d has %d cycles", id, c.Year, cyclesIn(c.Year))
+	}
+	return c, nil
+}
+
+// ID returns the four-digit identifier, e.g. "2602".
+func (c Cycle) ID() string {
+	return fmt.Sprintf("%02d%02d", c.Year%100, c.Number)
+}
+
+func (c Cycle) String() string { return c.ID() }
+
+// Effective returns the date the cycle takes effect, at 00:00 UTC.
+func (c Cycle) Effective() time.Time {
+	return firstOfYear(c.Year).AddDate(0, 0, 28*(c.Number-1))
+}
+
+// Expires returns the date the cycle is replaced by the next one, at 00:00
+// UTC.
+func (c Cycle) Expires() time.Time {
+	return c.Effective().AddDate(0, 0, 28)
+}
+
+// Contains reports whether the cycle is in effect at t.
+func (c Cycle) Contains(t time.Time) bool {
+	return At(t) == c
+}
+
+// Next returns the following cycle.
+func (c Cycle) Next() Cycle { return At(c.Expires()) }
+
+// Prev returns the preceding cycle.
+func (c Cycle) Prev() Cycle { return At(c.Effective().AddDate(0, 0, -1)) }
+
+// Before reports whether c is earlier than d.
+func (c Cycle) Before(d Cycle) bool {
+	return c.Year < d.Year || (c.Year == d.Year && c.Number < d.Number)
+}
+
+// firstOfYear returns the effective date of the first cycle of a year: the
+// first effective date on or after 1 January.
+func firstOfYear(year int) time.Time {
+	jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	days := int(jan1.Sub(epoch).Hours() / 24)
+	k := floorDiv(days+27, 28)
+	return epoch.AddDate(0, 0, 28*k)
+}
+
+// cyclesIn returns the number of cycles that take effect in a year, 13 or
+// occasionally 14.
+func cyclesIn(year int) int {
+	next := firstOfYear(year + 1)
+	return int(next.Sub(firstOfYear(year)).Hours()/24) / 28
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+var (
+	// "19_Feb_2026", as in CSV_Data/19_Feb_2026_CSV.zip.
+	underscoreDate = regexp.MustCompile(`(\d{1,2})_([A-Za-z]{3})_(\d{4})`)
+	// "2026-02-19", as in 28DaySubscription_Effective_2026-02-19.zip.
+	isoDate = regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})`)
+)
+
+// ParseFilename returns the cycle named by a subscription file name, such as
+// "28DaySubscription_Effective_2026-02-19.zip" or the inner
+// "CSV_Data/19_Feb_2026_CSV.zip". For change files that name two dates
+// ("19_Feb_2026-19_Mar_2026_CSV.zip") the first is used. The error wraps
+// ErrNotEffectiveDate when the date is not the start of a cycle.
+func ParseFilename(name string) (Cycle, error) {
+	var date time.Time
+	var err error
+	iso := isoDate.FindStringSubmatchIndex(name)
+	us := underscoreDate.FindStringSubmatchIndex(name)
+	switch {
+	case us != nil && (iso == nil || us[0] < iso[0]):
+		date, err = time.Parse("2_Jan_2006", normalizeMonth(name[us[0]:us[1]]))
+	case iso != nil:
+		date, err = time.Parse("2006-01-02", name[iso[0]:iso[1]])
+	default:
+		return Cycle{}, fmt.Errorf("no effective date in file name %q", name)
+	}
+	if err != nil {
+		return Cycle{}, fmt.Errorf("file name %q: %w", name, err)
+	}
+	return FromEffective(date)
+}
+
+// normalizeMonth title-cases the month abbreviation of a "19_FEB_2026" date
+// so time.Parse accepts it.
+func normalizeMonth(s string) string {
+	parts := strings.Split(s, "_")
+	parts[1] = strings.ToUpper(parts[1][:1]) + strings.ToLower(parts[1][1:])
+	return strings.Join(parts, "_")
+}
@@ -0,0 +1,105 @@
+package cycle
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEffective(t *testing.T) {
+	tests := []struct {
+		id   string
+		want time.Time
+	}{
+		{"2001", date(2020, 1, 2)},
+		{"2014", date(2020, 12, 31)},
+		{"2101", date(2021, 1, 28)},
+		{"2501", date(2025, 1, 23)},
+		{"2601", date(2026, 1, 22)},
+		{"2602", date(2026, 2, 19)},
+		{"2613", date(2026, 12, 24)},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.id)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", tt.id, err)
+		}
+		if got := c.Effective(); !got.Equal(tt.want) {
+			t.Errorf("%s effective %v, want %v", tt.id, got, tt.want)
+		}
+		if got := At(tt.want); got != c {
+			t.Errorf("At(%v) = %v, want %v", tt.want, got, c)
+		}
+		if c.ID() != tt.id {
+			t.Errorf("ID() = %s, want %s", c.ID(), tt.id)
+		}
+	}
+
+	for _, id := range []string{"2015", "2600", "2614", "26", "ab02"} {
+		if _, err := Parse(id); err == nil {
+			t.Errorf("Parse(%q): want error", id)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	c := At(time.Date(2026, 3, 18, 23, 59, 0, 0, time.UTC))
+	if c.ID() != "2602" || !c.Expires().Equal(date(2026, 3, 19)) {
+		t.Errorf("At(2026-03-18) = %v expiring %v", c, c.Expires())
+	}
+	if !c.Contains(date(2026, 2, 19)) || c.Contains(date(2026, 3, 19)) {
+		t.Error("2602 Contains: wrong boundaries")
+	}
+	// 1 January belongs to the last cycle of the previous year.
+	if c := At(date(2026, 1, 1)); c.ID() != "2513" {
+		t.Errorf("At(2026-01-01) = %v, want 2513", c)
+	}
+	if c := At(date(2019, 12, 31)); !c.Effective().Equal(date(2019, 12, 5)) {
+		t.Errorf("At(2019-12-31) effective %v, want 2019-12-05", c.Effective())
+	}
+}
+
+func TestNextPrev(t *testing.T) {
+	c, _ := Parse("2613")
+	if n := c.Next(); n.ID() != "2701" || !n.Effective().Equal(c.Expires()) {
+		t.Errorf("2613.Next() = %v effective %v", n, n.Effective())
+	}
+	if p := c.Next().Prev(); p != c {
+		t.Errorf("Next().Prev() = %v, want %v", p, c)
+	}
+	c, _ = Parse("2101")
+	if p := c.Prev(); p.ID() != "2014" {
+		t.Errorf("2101.Prev() = %v, want 2014", p)
+	}
+	if !c.Prev().Before(c) || c.Before(c.Prev()) {
+		t.Error("Before: wrong order")
+	}
+}
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"28DaySubscription_Effective_2026-02-19.zip", "2602"},
+		{"CSV_Data/19_Feb_2026_CSV.zip", "2602"},
+		{"22_JAN_2026_CSV.zip", "2601"},
+		{"19_Feb_2026-19_Mar_2026_CSV.zip", "2602"},
+	}
+	for _, tt := range tests {
+		c, err := ParseFilename(tt.name)
+		if err != nil || c.ID() != tt.want {
+			t.Errorf("ParseFilename(%q) = %v, %v; want %s", tt.name, c, err, tt.want)
+		}
+	}
+	if _, err := ParseFilename("20_Feb_2026_CSV.zip"); !errors.Is(err, ErrNotEffectiveDate) {
+		t.Errorf("ParseFilename(20_Feb_2026) error = %v, want ErrNotEffectiveDate", err)
+	}
+	if _, err := ParseFilename("28DaySubscription_test.zip"); err == nil {
+		t.Error("ParseFilename without a date: want error")
+	}
+}
@@ -0,0 +1,77 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/IdahoAvionics/go-nasr/cycle"
+)
+
+func TestExtract_Cycle(t *testing.T) {
+	db := openTestDB(t)
+
+	c, err := DatabaseCycle(db)
+	if err != nil {
+		t.Fatalf("DatabaseCycle: %v", err)
+	}
+	if c.ID() != "2602" {
+		t.Errorf("cycle = %s, want 2602", c)
+	}
+	var eff, exp, src string
+	err = db.QueryRow(`SELECT EFFECTIVE_DATE, EXPIRATION_DATE, SOURCE FROM NASR_CYCLE`).Scan(&eff, &exp, &src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eff != "2026/02/19" || exp != "2026/03/19" || src != "CSV_Data/19_Feb_2026_CSV.zip" {
+		t.Errorf("NASR_CYCLE = %s, %s, %s", eff, exp, src)
+	}
+
+	// Every group's EFF_DATE is on or before the subscription's.
+	var later int
+	if err := db.QueryRow(`SELECT count(*) FROM AWY_BASE WHERE EFF_DATE > ?`, eff).Scan(&later); err != nil || later != 0 {
+		t.Errorf("AWY_BASE rows after %s: %d, %v", eff, later, err)
+	}
+}
+
+func TestSubscriptionCycle(t *testing.T) {
+	tests := []struct {
+		outer, inner string
+		want         string
+		ok, fails    bool
+	}{
+		{"28DaySubscription_test.zip", "CSV_Data/19_Feb_2026_CSV.zip", "2602", true, false},
+		{"/tmp/28DaySubscription_Effective_2026-02-19.zip", "CSV_Data/19_Feb_2026_CSV.zip", "2602", true, false},
+		{"28DaySubscription_Effective_2026-01-22.zip", "CSV_Data/19_Feb_2026_CSV.zip", "", false, true},
+		{"28DaySubscription_test.zip", "CSV_Data/20_Feb_2026_CSV.zip", "", false, true},
+		{"28DaySubscription_test.zip", "CSV_Data/current_CSV.zip", "", false, false},
+	}
+	for _, tt := range tests {
+		c, ok, err := subscriptionCycle(tt.outer, tt.inner)
+		if (err != nil) != tt.fails || ok != tt.ok || (ok && c.ID() != tt.want) {
+			t.Errorf("subscriptionCycle(%q, %q) = %v, %v, %v", tt.outer, tt.inner, c, ok, err)
+		}
+	}
+}
+
+func TestDatabaseCycle_NotRecorded(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	createTables, _ := generateDDL(map[string]*tableSchema{"NASR_CYCLE": nasrCycleSchema}, nil)
+	if _, err := db.Exec(createTables[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DatabaseCycle(db); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DatabaseCycle on empty NASR_CYCLE = %v, want ErrNotFound", err)
+	}
+	c, _ := cycle.Parse("2603")
+	if err := recordCycle(db, c, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DatabaseCycle(db); err != nil || got != c {
+		t.Errorf("DatabaseCycle = %v, %v; want 2603", got, err)
+	}
+}
@@ -0,0 +1,550 @@
+package nasr
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeType classifies a changed row.
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// KeyField is one column value of a RowKey.
+type KeyField struct {
+	Column string
+	Value  interface{}
+}
+
+// RowKey is the natural key of a row, in key column order. It marshals to a
+// JSON object.
+type RowKey []KeyField
+
+// String formats the key as "SITE_NO=04508.*A RWY_ID=10/28".
+func (k RowKey) String() string {
+	parts := make([]string, len(k))
+	for i, f := range k {
+		parts[i] = f.Column + "=" + formatValue(f.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Get returns the value of a key column, or nil.
+func (k RowKey) Get(column string) interface{} {
+	for _, f := range k {
+		if f.Column == column {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+func (k RowKey) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, f := range k {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		col, _ := json.Marshal(f.Column)
+		val, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(col)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// ColumnChange is a column whose value differs between two cycles.
+type ColumnChange struct {
+	Column string      `json:"column"`
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+}
+
+// Change is a row added, removed or modified between two cycles.
+type Change struct {
+	Table   string                 `json:"table"`
+	Type    ChangeType             `json:"type"`
+	Key     RowKey                 `json:"key"`
+	Columns []ColumnChange         `json:"columns,omitempty"` // modified rows only
+	Row     map[string]interface{} `json:"row"`               // the old row if removed, else the new row
+}
+
+// ChangeSet is the result of Diff.
+type ChangeSet struct {
+	OldCycle string   `json:"old_cycle,omitempty"` // from NASR_CYCLE, if recorded
+	NewCycle string   `json:"new_cycle,omitempty"`
+	Changes  []Change `json:"changes"`
+}
+
+// diffIgnoredColumns are not compared: EFF_DATE moves whenever the FAA
+// republishes a group, whether or not its values changed.
+var diffIgnoredColumns = map[string]bool{"EFF_DATE": true}
+
+// diffKeyColumns lists, for each table that is not a foreign key parent, the
+// columns that identify a row after its foreign key columns. For standalone
+// tables, which have no foreign key, they are the whole key.
+var diffKeyColumns = map[string][]string{
+	"APT_ARS":     {"ARREST_DEVICE_CODE"},
+	"APT_ATT":     {"SKED_SEQ_NO"},
+	"APT_CON":     {"TITLE"},
+	"APT_RMK":     {"TAB_NAME", "REF_COL_NAME", "ELEMENT", "REF_COL_SEQ_NO"},
+	"ARB_SEG":     {"REC_ID"},
+	"ATC_ATIS":    {"ATIS_NO"},
+	"ATC_RMK":     {"TAB_NAME", "REF_COL_NAME", "REMARK_NO"},
+	"ATC_SVC":     {"CTL_SVC"},
+	"AWOS":        {"ASOS_AWOS_ID", "ASOS_AWOS_TYPE"},
+	"AWY_SEG_ALT": {"POINT_SEQ"},
+	"CDR":         {"RCODE"},
+	"CLS_ARSP":    {"SITE_NO"},
+	"COM":         {"COMM_LOC_ID", "COMM_TYPE", "COMM_OUTLET_NAME"},
+	"DP_APT":      {"BODY_SEQ", "ARPT_ID", "RWY_END_ID"},
+	"DP_RTE":      {"ROUTE_PORTION_TYPE", "ROUTE_NAME", "BODY_SEQ", "TRANSITION_COMPUTER_CODE", "POINT_SEQ"},
+	"FIX_CHRT":    {"CHARTING_TYPE_DESC"},
+	"FIX_NAV":     {"NAV_ID", "NAV_TYPE"},
+	"FRQ":         {"FACILITY", "FACILITY_TYPE", "SERVICED_FACILITY", "FREQ", "FREQ_USE", "SECTORIZATION"},
+	"FSS_RMK":     {"REF_COL_NAME", "REF_COL_SEQ_NO"},
+	"HPF_CHRT":    {"CHARTING_TYPE_DESC"},
+	"HPF_RMK":     {"TAB_NAME", "REF_COL_NAME", "REF_COL_SEQ_NO"},
+	"HPF_SPD_ALT": {"SPEED_RANGE"},
+	"ILS_DME":     {},
+	"ILS_GS":      {},
+	"ILS_MKR":     {"ILS_COMP_TYPE_CODE"},
+	"ILS_RMK":     {"TAB_NAME", "ILS_COMP_TYPE_CODE", "REF_COL_NAME", "REF_COL_SEQ_NO"},
+	"LID":         {"COUNTRY_CODE", "LOC_ID"},
+	"MAA_CON":     {"FREQ_SEQ"},
+	"MAA_RMK":     {"TAB_NAME", "REF_COL_NAME", "REF_COL_SEQ_NO"},
+	"MAA_SHP":     {"POINT_SEQ"},
+	"MIL_OPS":     {"SITE_NO"},
+	"MTR_AGY":     {"AGENCY_TYPE"},
+	"MTR_PT":      {"ROUTE_PT_SEQ"},
+	"MTR_SOP":     {"SOP_SEQ_NO"},
+	"MTR_TERR":    {"TERRAIN_SEQ_NO"},
+	"MTR_WDTH":    {"WIDTH_SEQ_NO"},
+	"NAV_CKPT":    {"AIR_GND_CODE", "BRG", "ALTITUDE"},
+	"NAV_RMK":     {"TAB_NAME", "REF_COL_NAME", "REF_COL_SEQ_NO"},
+	"PFR_RMT_FMT": {"Orig", "Dest", "Type", "Seq"},
+	"PFR_SEG":     {"SEGMENT_SEQ"},
+	"PJA_CON":     {"FAC_ID", "COMMERCIAL_FREQ"},
+	"RDR":         {"FACILITY_ID", "FACILITY_TYPE", "RADAR_TYPE", "RADAR_NO"},
+	"STAR_APT":    {"BODY_SEQ", "ARPT_ID", "RWY_END_ID"},
+	"STAR_RTE":    {"ROUTE_PORTION_TYPE", "ROUTE_NAME", "BODY_SEQ", "TRANSITION_COMPUTER_CODE", "POINT_SEQ"},
+	"WXL_SVC":     {"WEA_SVC_TYPE_CODE"},
+}
+
+// naturalKeys returns the key columns of every FAA table. A foreign key
+// parent is keyed by its referenced columns, which have a unique index; any
+// other table by its foreign key columns followed by diffKeyColumns.
+func naturalKeys() map[string][]string {
+	keys := make(map[string][]string)
+	for _, fk := range foreignKeyDefs() {
+		keys[fk.parentTable] = fk.columns
+	}
+	for _, fk := range foreignKeyDefs() {
+		if _, ok := keys[fk.childTable]; ok || isDerivedTable(fk.childTable) {
+			continue
+		}
+		keys[fk.childTable] = append(append([]string(nil), fk.columns...), diffKeyColumns[fk.childTable]...)
+	}
+	for table, cols := range diffKeyColumns {
+		if _, ok := keys[table]; !ok {
+			keys[table] = cols
+		}
+	}
+	return keys
+}
+
+// isDerivedTable reports whether a table is computed by Extract rather than
+// loaded from an FAA CSV file.
+func isDerivedTable(name string) bool {
+	if name == arbPolygonSchema.name || name == nasrCycleSchema.name || name == rejectTable {
+		return true
+	}
+	for _, d := range explodeDefs() {
+		if d.child == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff compares two databases built by Extract, normally from consecutive
+// cycles, and returns the rows added, removed and modified in newDB. Rows
+// are matched by their table's natural key; rows sharing a key are paired in
+// rowid order. EFF_DATE is not compared, and derived tables are skipped
+// since they follow from the FAA tables.
+func Diff(oldDB, newDB *sql.DB) (*ChangeSet, error) {
+	cs := &ChangeSet{}
+	if c, err := DatabaseCycle(oldDB); err == nil {
+		cs.OldCycle = c.ID()
+	}
+	if c, err := DatabaseCycle(newDB); err == nil {
+		cs.NewCycle = c.ID()
+	}
+
+	keys := naturalKeys()
+	tables := make([]string, 0, len(keys))
+	for t := range keys {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		changes, err := diffTable(oldDB, newDB, table, keys[table])
+		if err != nil {
+			return nil, fmt.Errorf("diff %s: %w", table, err)
+		}
+		cs.Changes = append(cs.Changes, changes...)
+	}
+	return cs, nil
+}
+
+// diffRows is a table's rows grouped by natural key, each group in rowid
+// order.
+type diffRows struct {
+	cols   []string
+	keys   map[string]RowKey
+	groups map[string][]map[string]interface{}
+}
+
+// loadTableRows loads every row of a table. A table missing from db has no
+// rows.
+func loadTableRows(db *sql.DB, table string, keyCols []string) (*diffRows, error) {
+	exists, err := tableExists(db, table)
+	if err != nil || !exists {
+		return &diffRows{keys: map[string]RowKey{}, groups: map[string][]map[string]interface{}{}}, err
+	}
+	return loadDiffRows(db, keyCols, fmt.Sprintf(`SELECT * FROM %q ORDER BY rowid`, table))
+}
+
+// loadDiffRows groups the rows returned by a query by natural key.
+func loadDiffRows(db queryer, keyCols []string, query string, args ...interface{}) (*diffRows, error) {
+	d := &diffRows{keys: map[string]RowKey{}, groups: map[string][]map[string]interface{}{}}
+	cols, rows, err := queryRows(db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cols {
+		d.cols = append(d.cols, c.name)
+	}
+	for _, row := range rows {
+		m := rowMap(cols, row)
+		for c, v := range m {
+			if b, ok := v.([]byte); ok {
+				m[c] = string(b)
+			}
+		}
+		key := make(RowKey, len(keyCols))
+		for i, c := range keyCols {
+			key[i] = KeyField{c, m[c]}
+		}
+		id := keyID(key)
+		d.keys[id] = key
+		d.groups[id] = append(d.groups[id], m)
+	}
+	return d, nil
+}
+
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+	return n > 0, err
+}
+
+// keyID encodes a key as a map key, keeping NULL distinct from "".
+func keyID(k RowKey) string {
+	var b strings.Builder
+	for _, f := range k {
+		if f.Value == nil {
+			b.WriteString("\x00N")
+			continue
+		}
+		b.WriteString("\x00V")
+		b.WriteString(formatValue(f.Value))
+	}
+	return b.String()
+}
+
+func diffTable(oldDB, newDB *sql.DB, table string, keyCols []string) ([]Change, error) {
+	old, err := loadTableRows(oldDB, table, keyCols)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := loadTableRows(newDB, table, keyCols)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := sharedColumns(old.cols, cur.cols)
+	if old.cols != nil && cur.cols != nil && (len(cols) != len(old.cols) || len(cols) != len(cur.cols)) {
+		log.Printf("WARNING: %s columns differ between cycles; comparing the %d they share", table, len(cols))
+	}
+
+	var changes []Change
+	matchRows(old, cur, func(key RowKey, o, n map[string]interface{}) {
+		switch {
+		case n == nil:
+			changes = append(changes, Change{Table: table, Type: ChangeRemoved, Key: key, Row: o})
+		case o == nil:
+			changes = append(changes, Change{Table: table, Type: ChangeAdded, Key: key, Row: n})
+		default:
+			if cc := changedColumns(cols, o, n); cc != nil {
+				changes = append(changes, Change{Table: table, Type: ChangeModified, Key: key, Columns: cc, Row: n})
+			}
+		}
+	})
+	return changes, nil
+}
+
+// sharedColumns returns the columns of b that a also has, in b's order.
+func sharedColumns(a, b []string) []string {
+	inA := map[string]bool{}
+	for _, c := range a {
+		inA[c] = true
+	}
+	var cols []string
+	for _, c := range b {
+		if inA[c] {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// matchRows pairs the rows of old and cur with the same key, in key order
+// and then rowid order, and calls fn for each pair. o is nil for an added
+// row and n for a removed one.
+func matchRows(old, cur *diffRows, fn func(key RowKey, o, n map[string]interface{})) {
+	ids := make([]string, 0, len(cur.keys))
+	for id := range cur.keys {
+		ids = append(ids, id)
+	}
+	for id := range old.keys {
+		if _, ok := cur.keys[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	keyOf := func(id string) RowKey {
+		if k, ok := cur.keys[id]; ok {
+			return k
+		}
+		return old.keys[id]
+	}
+	sort.Slice(ids, func(i, j int) bool { return compareKeys(keyOf(ids[i]), keyOf(ids[j])) < 0 })
+
+	for _, id := range ids {
+		o, n := old.groups[id], cur.groups[id]
+		for i := 0; i < len(o) || i < len(n); i++ {
+			var or, nr map[string]interface{}
+			if i < len(o) {
+				or = o[i]
+			}
+			if i < len(n) {
+				nr = n[i]
+			}
+			fn(keyOf(id), or, nr)
+		}
+	}
+}
+
+// changedColumns compares two rows, skipping diffIgnoredColumns.
+func changedColumns(cols []string, o, n map[string]interface{}) []ColumnChange {
+	var cc []ColumnChange
+	for _, c := range cols {
+		if !diffIgnoredColumns[c] && !valuesEqual(o[c], n[c]) {
+			cc = append(cc, ColumnChange{c, o[c], n[c]})
+		}
+	}
+	return cc
+}
+
+// valuesEqual compares two column values, numerically if both are numbers.
+func valuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	fa, aNum := numericValue(a)
+	fb, bNum := numericValue(b)
+	if aNum && bNum {
+		return fa == fb
+	}
+	if aNum != bNum {
+		return false
+	}
+	return formatValue(a) == formatValue(b)
+}
+
+func numericValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// compareKeys orders keys column by column: NULL first, numbers numerically,
+// everything else as text.
+func compareKeys(a, b RowKey) int {
+	for i := range a {
+		if i >= len(b) {
+			return 1
+		}
+		x, y := a[i].Value, b[i].Value
+		switch {
+		case x == nil && y == nil:
+			continue
+		case x == nil:
+			return -1
+		case y == nil:
+			return 1
+		}
+		fx, xNum := numericValue(x)
+		fy, yNum := numericValue(y)
+		if xNum && yNum {
+			if fx != fy {
+				if fx < fy {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(formatValue(x), formatValue(y)); c != 0 {
+			return c
+		}
+	}
+	if len(a) < len(b) {
+		return -1
+	}
+	return 0
+}
+
+// formatValue formats a column value for keys and reports.
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// WriteJSON writes the change set as an indented JSON document.
+func (cs *ChangeSet) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cs)
+}
+
+// WriteReport writes a human-readable report: a count of changes for each
+// table, followed by one line per changed row and per changed column.
+func (cs *ChangeSet) WriteReport(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "NASR changes from cycle %s to %s: %d rows\n",
+		cycleOrUnknown(cs.OldCycle), cycleOrUnknown(cs.NewCycle), len(cs.Changes)); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(cs.Changes); {
+		table := cs.Changes[i].Table
+		j := i
+		counts := map[ChangeType]int{}
+		for ; j < len(cs.Changes) && cs.Changes[j].Table == table; j++ {
+			counts[cs.Changes[j].Type]++
+		}
+		fmt.Fprintf(&b, "\n%s: %d added, %d removed, %d modified\n", table, counts[ChangeAdded], counts[ChangeRemoved], counts[ChangeModified])
+		for _, c := range cs.Changes[i:j] {
+			switch c.Type {
+			case ChangeAdded:
+				fmt.Fprintf(&b, "  + %s\n", c.Key)
+			case ChangeRemoved:
+				fmt.Fprintf(&b, "  - %s\n", c.Key)
+			case ChangeModified:
+				fmt.Fprintf(&b, "  ~ %s\n", c.Key)
+				for _, cc := range c.Columns {
+					fmt.Fprintf(&b, "      %s: %q -> %q\n", cc.Column, formatValue(cc.Old), formatValue(cc.New))
+				}
+			}
+		}
+		i = j
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// changesDDL creates the CHANGES table. A modified row has one row per
+// changed column; an added or removed row has one row with no column.
+const changesDDL = `CREATE TABLE IF NOT EXISTS "CHANGES" (
+	"OLD_CYCLE" TEXT,
+	"NEW_CYCLE" TEXT,
+	"TABLE_NAME" TEXT NOT NULL,
+	"CHANGE_TYPE" TEXT NOT NULL,
+	"KEY" TEXT NOT NULL,
+	"COLUMN_NAME" TEXT,
+	"OLD_VALUE",
+	"NEW_VALUE"
+)`
+
+// WriteTable appends the change set to a CHANGES table in db, creating the
+// table if needed. KEY holds the key as formatted by RowKey.String.
+func (cs *ChangeSet) WriteTable(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(changesDDL); err != nil {
+		return fmt.Errorf("create CHANGES: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT INTO "CHANGES" VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	nullIfEmpty := func(s string) interface{} {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	oldCycle, newCycle := nullIfEmpty(cs.OldCycle), nullIfEmpty(cs.NewCycle)
+	for _, c := range cs.Changes {
+		key := c.Key.String()
+		if c.Type != ChangeModified {
+			if _, err := stmt.Exec(oldCycle, newCycle, c.Table, string(c.Type), key, nil, nil, nil); err != nil {
+				return fmt.Errorf("insert CHANGES: %w", err)
+			}
+			continue
+		}
+		for _, cc := range c.Columns {
+			if _, err := stmt.Exec(oldCycle, newCycle, c.Table, string(c.Type), key, cc.Column, cc.Old, cc.New); err != nil {
+				return fmt.Errorf("insert CHANGES: %w", err)
+			}
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package nasr
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyTestDB returns a writable copy of the test database.
+func copyTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "copy.db")
+	if _, err := openTestDB(t).Exec(`VACUUM INTO ?`, path); err != nil {
+		t.Fatalf("copy test db: %v", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open copy: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNaturalKeys(t *testing.T) {
+	keys := naturalKeys()
+	db := openTestDB(t)
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		if _, ok := keys[name]; !ok && !isDerivedTable(name) {
+			t.Errorf("%s has no natural key", name)
+		}
+	}
+	if got := strings.Join(keys["APT_RWY_END"], ","); got != "SITE_NO,RWY_ID,RWY_END_ID" {
+		t.Errorf("APT_RWY_END key = %s", got)
+	}
+	if got := strings.Join(keys["FIX_NAV"], ","); got != "FIX_ID,ICAO_REGION_CODE,NAV_ID,NAV_TYPE" {
+		t.Errorf("FIX_NAV key = %s", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := openTestDB(t)
+	cur := copyTestDB(t)
+
+	cs, err := Diff(old, cur)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(cs.Changes) != 0 || cs.OldCycle != "2602" || cs.NewCycle != "2602" {
+		t.Fatalf("identical databases: %d changes, cycles %q %q", len(cs.Changes), cs.OldCycle, cs.NewCycle)
+	}
+
+	var siteNo, fixID string
+	cur.QueryRow(`SELECT SITE_NO FROM APT_BASE ORDER BY rowid LIMIT 1`).Scan(&siteNo)
+	cur.QueryRow(`SELECT FIX_ID FROM FIX_CHRT ORDER BY rowid LIMIT 1`).Scan(&fixID)
+	for _, q := range []string{
+		// A new EFF_DATE alone is not a change.
+		`UPDATE APT_BASE SET ARPT_NAME = 'RENAMED', EFF_DATE = '2099/01/01' WHERE rowid = 1`,
+		`UPDATE APT_BASE SET EFF_DATE = '2099/01/01' WHERE rowid = 2`,
+		`DELETE FROM FIX_CHRT WHERE rowid = (SELECT min(rowid) FROM FIX_CHRT)`,
+		// A second row with the same key is added after the first.
+		`INSERT INTO FIX_NAV SELECT * FROM FIX_NAV ORDER BY rowid LIMIT 1`,
+	} {
+		if _, err := cur.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+
+	cs, err = Diff(old, cur)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	got := map[string]Change{}
+	for _, c := range cs.Changes {
+		got[c.Table] = c
+	}
+	if len(cs.Changes) != 3 {
+		t.Fatalf("got %d changes, want 3: %+v", len(cs.Changes), cs.Changes)
+	}
+	if c := got["APT_BASE"]; c.Type != ChangeModified || c.Key.Get("SITE_NO") != siteNo ||
+		len(c.Columns) != 1 || c.Columns[0].Column != "ARPT_NAME" || c.Columns[0].New != "RENAMED" {
+		t.Errorf("APT_BASE change = %+v", c)
+	}
+	if c := got["FIX_CHRT"]; c.Type != ChangeRemoved || c.Key.Get("FIX_ID") != fixID || c.Row["FIX_ID"] != fixID {
+		t.Errorf("FIX_CHRT change = %+v", c)
+	}
+	if c := got["FIX_NAV"]; c.Type != ChangeAdded {
+		t.Errorf("FIX_NAV change = %+v", c)
+	}
+
+	var report bytes.Buffer
+	if err := cs.WriteReport(&report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"from cycle 2602 to 2602: 3 rows",
+		"APT_BASE: 0 added, 0 removed, 1 modified",
+		"~ SITE_NO=" + siteNo,
+		`ARPT_NAME: "`,
+		`-> "RENAMED"`,
+		"- FIX_ID=" + fixID,
+	} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, report.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := cs.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		NewCycle string `json:"new_cycle"`
+		Changes  []struct {
+			Table string            `json:"table"`
+			Type  string            `json:"type"`
+			Key   map[string]string `json:"key"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(js.Bytes(), &doc); err != nil {
+		t.Fatalf("JSON: %v\n%s", err, js.String())
+	}
+	if doc.NewCycle != "2602" || len(doc.Changes) != 3 || doc.Changes[0].Key["SITE_NO"] != siteNo {
+		t.Errorf("JSON = %+v", doc)
+	}
+
+	if err := cs.WriteTable(cur); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	var n int
+	var col sql.NullString
+	if err := cur.QueryRow(`SELECT count(*), max(COLUMN_NAME) FROM CHANGES WHERE NEW_CYCLE = '2602'`).Scan(&n, &col); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || col.String != "ARPT_NAME" {
+		t.Errorf("CHANGES has %d rows, column %v", n, col)
+	}
+}
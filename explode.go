@@ -0,0 +1,225 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// explodeDef describes a list-valued column that is split into a child table
+// with one row per list item. The child table holds the key columns copied
+// from the source row followed by the item's value columns.
+type explodeDef struct {
+	child     string   // child table name, e.g. "DP_RTE_RWY"
+	table     string   // source table, e.g. "DP_RTE"
+	column    string   // list-valued source column, e.g. "ARPT_RWY_ASSOC"
+	keys      []string // source columns identifying the parent row
+	values    []string // child value column names, e.g. "ARPT_ID", "RWY_END_ID"
+	split     func(string) [][]string
+	valueType string // data type of the value columns, "TEXT" if empty
+}
+
+// explodeDefs lists the list-valued columns split into child tables. Their
+// foreign keys are declared in foreignKeyDefs like any other table's.
+func explodeDefs() []explodeDef {
+	return []explodeDef{
+		{
+			child:  "DP_RTE_RWY",
+			table:  "DP_RTE",
+			column: "ARPT_RWY_ASSOC",
+			keys:   []string{"DP_COMPUTER_CODE", "ROUTE_PORTION_TYPE", "ROUTE_NAME", "BODY_SEQ", "TRANSITION_COMPUTER_CODE", "POINT_SEQ", "POINT"},
+			values: []string{"ARPT_ID", "RWY_END_ID"},
+			split:  splitRunwayAssoc,
+		},
+		{
+			child:  "STAR_RTE_RWY",
+			table:  "STAR_RTE",
+			column: "ARPT_RWY_ASSOC",
+			keys:   []string{"STAR_COMPUTER_CODE", "ROUTE_PORTION_TYPE", "ROUTE_NAME", "BODY_SEQ", "TRANSITION_COMPUTER_CODE", "POINT_SEQ", "POINT"},
+			values: []string{"ARPT_ID", "RWY_END_ID"},
+			split:  splitRunwayAssoc,
+		},
+		{
+			child:  "APT_FUEL",
+			table:  "APT_BASE",
+			column: "FUEL_TYPES",
+			keys:   []string{"SITE_NO"},
+			values: []string{"FUEL_TYPE"},
+			split:  splitList(","),
+		},
+		{
+			child:  "APT_SVC",
+			table:  "APT_BASE",
+			column: "OTHER_SERVICES",
+			keys:   []string{"SITE_NO"},
+			values: []string{"SERVICE"},
+			split:  splitList(","),
+		},
+		{
+			child:  "APT_AFRM_RPR",
+			table:  "APT_BASE",
+			column: "AIRFRAME_REPAIR_SER_CODE",
+			keys:   []string{"SITE_NO"},
+			values: []string{"AIRFRAME_REPAIR_SER_CODE"},
+			split:  splitList("/", "NONE"),
+		},
+		{
+			child:  "APT_OXY",
+			table:  "APT_BASE",
+			column: "BOTTLED_OXY_TYPE",
+			keys:   []string{"SITE_NO"},
+			values: []string{"BOTTLED_OXY_TYPE"},
+			split:  splitList("/", "NONE"),
+		},
+		{
+			child:  "MAA_ARPT",
+			table:  "MAA_BASE",
+			column: "ARPT_IDS",
+			keys:   []string{"MAA_ID"},
+			values: []string{"ARPT_ID"},
+			split:  splitList(", ;"),
+		},
+	}
+}
+
+// splitRunwayAssoc splits an ARPT_RWY_ASSOC value into airport and runway
+// pairs. The runway is empty when all runways of the airport are served.
+func splitRunwayAssoc(s string) [][]string {
+	var out [][]string
+	for _, a := range ParseRunwayAssoc(s) {
+		out = append(out, []string{a.Airport, a.Runway})
+	}
+	return out
+}
+
+// splitList returns a split function for single-valued items separated by
+// any of the characters in seps. Items are trimmed; empty items and the
+// omitted values (such as "NONE") are dropped.
+func splitList(seps string, omit ...string) func(string) [][]string {
+	return func(s string) [][]string {
+		var out [][]string
+		for _, item := range strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(seps, r) }) {
+			item = strings.TrimSpace(item)
+			skip := item == ""
+			for _, o := range omit {
+				skip = skip || item == o
+			}
+			if !skip {
+				out = append(out, []string{item})
+			}
+		}
+		return out
+	}
+}
+
+// explodeSchemas returns the child table schemas for the explode definitions
+// whose source table is present. Key columns keep the source column types.
+func explodeSchemas(tables map[string]*tableSchema) []*tableSchema {
+	var out []*tableSchema
+	for _, d := range explodeDefs() {
+		src, ok := tables[d.table]
+		if !ok {
+			continue
+		}
+		ts := &tableSchema{name: d.child}
+		for _, k := range d.keys {
+			for _, c := range src.columns {
+				if c.name == k {
+					ts.columns = append(ts.columns, c)
+				}
+			}
+		}
+		vt := d.valueType
+		if vt == "" {
+			vt = "TEXT"
+		}
+		for i, v := range d.values {
+			// The first value column is the item itself and always present.
+			ts.columns = append(ts.columns, columnDef{name: v, dataType: vt, nullable: i > 0})
+		}
+		out = append(out, ts)
+	}
+	return out
+}
+
+// buildExplodedTables fills each child table from the list-valued column of
+// its source table. Duplicate items within one source value are stored once.
+func buildExplodedTables(db *sql.DB, tables map[string]*tableSchema) error {
+	for _, d := range explodeDefs() {
+		if _, ok := tables[d.child]; !ok {
+			continue
+		}
+		if err := buildExplodedTable(db, d); err != nil {
+			return fmt.Errorf("build %s: %w", d.child, err)
+		}
+	}
+	return nil
+}
+
+func buildExplodedTable(db *sql.DB, d explodeDef) error {
+	quoted := make([]string, len(d.keys))
+	for i, k := range d.keys {
+		quoted[i] = fmt.Sprintf("%q", k)
+	}
+	rows, err := db.Query(fmt.Sprintf(`SELECT %s, %q FROM %q WHERE %q IS NOT NULL AND %q != '' ORDER BY rowid`,
+		strings.Join(quoted, ", "), d.column, d.table, d.column, d.column))
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		keys  []interface{}
+		value string
+	}
+	var all []pending
+	for rows.Next() {
+		dest := make([]interface{}, len(d.keys)+1)
+		ptrs := make([]interface{}, len(dest))
+		for i := range dest {
+			ptrs[i] = &dest[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, pending{dest[:len(d.keys)], asString(dest[len(d.keys)])})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(d.keys)+len(d.values)), ", ")
+	stmt, err := tx.Prepare(fmt.Sprintf(`INSERT INTO %q VALUES (%s)`, d.child, placeholders))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range all {
+		seen := make(map[string]bool)
+		for _, item := range d.split(p.value) {
+			k := strings.Join(item, "\x00")
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			args := append([]interface{}(nil), p.keys...)
+			for _, v := range item {
+				if v == "" {
+					args = append(args, nil)
+				} else {
+					args = append(args, v)
+				}
+			}
+			if _, err := stmt.Exec(args...); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
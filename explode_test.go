@@ -0,0 +1,113 @@
+package nasr
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestSplitRunwayAssoc(t *testing.T) {
+	got := splitRunwayAssoc("57C/08, 57C/26, BUU/11, ENW")
+	want := [][]string{{"57C", "08"}, {"57C", "26"}, {"BUU", "11"}, {"ENW", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitRunwayAssoc = %v, want %v", got, want)
+	}
+}
+
+func TestExtract_RunwayAssocTables(t *testing.T) {
+	db := openTestDB(t)
+
+	rows, err := db.Query(`SELECT DISTINCT DP_COMPUTER_CODE FROM DP_RTE_RWY
+WHERE ARPT_ID = 'MKE' AND (RWY_END_ID = '01L' OR RWY_END_ID IS NULL)`)
+	if err != nil {
+		t.Fatalf("query DP_RTE_RWY: %v", err)
+	}
+	defer rows.Close()
+	var codes []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			t.Fatal(err)
+		}
+		codes = append(codes, c)
+	}
+	if !reflect.DeepEqual(codes, []string{"ACCRA5.ACCRA"}) {
+		t.Errorf("SIDs serving MKE 01L = %v, want [ACCRA5.ACCRA]", codes)
+	}
+
+	// ENW is listed without a runway: all runways.
+	var rwy sql.NullString
+	err = db.QueryRow(`SELECT RWY_END_ID FROM DP_RTE_RWY WHERE DP_COMPUTER_CODE = 'ACCRA5.ACCRA' AND ARPT_ID = 'ENW'`).Scan(&rwy)
+	if err != nil || rwy.Valid {
+		t.Errorf("ENW runway = %v, %v; want NULL", rwy, err)
+	}
+
+	// One child row per pair in every source value.
+	var pairs, srcRows int
+	if err := db.QueryRow(`SELECT count(*) FROM STAR_RTE_RWY`).Scan(&pairs); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM STAR_RTE WHERE ARPT_RWY_ASSOC IS NOT NULL`).Scan(&srcRows); err != nil {
+		t.Fatal(err)
+	}
+	if srcRows == 0 || pairs < srcRows {
+		t.Errorf("STAR_RTE_RWY has %d rows for %d STAR_RTE values", pairs, srcRows)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		split func(string) [][]string
+		in    string
+		want  [][]string
+	}{
+		{splitList(","), "100LL,A,A+", [][]string{{"100LL"}, {"A"}, {"A+"}}},
+		{splitList(","), " ,", nil},
+		{splitList("/", "NONE"), "HIGH/LOW", [][]string{{"HIGH"}, {"LOW"}}},
+		{splitList("/", "NONE"), "NONE", nil},
+		{splitList(", ;"), "JFX, DKB;ACQ", [][]string{{"JFX"}, {"DKB"}, {"ACQ"}}},
+	}
+	for _, tt := range tests {
+		if got := tt.split(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExtract_AirportListTables(t *testing.T) {
+	db := openTestDB(t)
+
+	// Airports selling both 100LL and Jet A, from the child table and from
+	// the packed source column.
+	var exploded, packed int
+	err := db.QueryRow(`SELECT count(*) FROM APT_BASE a
+WHERE EXISTS (SELECT 1 FROM APT_FUEL f WHERE f.SITE_NO = a.SITE_NO AND f.FUEL_TYPE = '100LL')
+  AND EXISTS (SELECT 1 FROM APT_FUEL f WHERE f.SITE_NO = a.SITE_NO AND f.FUEL_TYPE = 'A')`).Scan(&exploded)
+	if err != nil {
+		t.Fatalf("query APT_FUEL: %v", err)
+	}
+	err = db.QueryRow(`SELECT count(*) FROM APT_BASE
+WHERE ',' || FUEL_TYPES || ',' LIKE '%,100LL,%' AND ',' || FUEL_TYPES || ',' LIKE '%,A,%'`).Scan(&packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exploded == 0 || exploded != packed {
+		t.Errorf("100LL and Jet A airports: %d from APT_FUEL, %d from FUEL_TYPES", exploded, packed)
+	}
+
+	for _, q := range []string{
+		`SELECT count(*) FROM APT_SVC`,
+		`SELECT count(*) FROM APT_OXY WHERE BOTTLED_OXY_TYPE IN ('HIGH', 'LOW')`,
+		`SELECT count(*) FROM MAA_ARPT`,
+	} {
+		var n int
+		if err := db.QueryRow(q).Scan(&n); err != nil || n == 0 {
+			t.Errorf("%s = %d, %v", q, n, err)
+		}
+	}
+	var none int
+	db.QueryRow(`SELECT count(*) FROM APT_OXY WHERE BOTTLED_OXY_TYPE = 'NONE'`).Scan(&none)
+	if none != 0 {
+		t.Errorf("APT_OXY has %d NONE rows", none)
+	}
+}
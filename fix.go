@@ -0,0 +1,189 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fix is a FIX_BASE row with the charts it appears on and the navaid
+// radials that define it.
+type Fix struct {
+	ID        string // FIX_ID
+	Region    string // ICAO_REGION_CODE, e.g. "K2"
+	State     string // STATE_CODE
+	Country   string // COUNTRY_CODE
+	Lat, Lon  float64
+	Use       string // FIX_USE_CODE, e.g. "WP" or "RP"
+	ARTCCHigh string // ARTCC_ID_HIGH
+	ARTCCLow  string // ARTCC_ID_LOW
+
+	Charts  []string // FIX_CHRT.CHARTING_TYPE_DESC, e.g. "IAP"
+	Radials []FixRadial
+
+	// DistanceNM is the distance from the reference point passed to
+	// ResolveFix, or zero when none was given.
+	DistanceNM float64
+}
+
+// FixRadial is a FIX_NAV definition of a fix by a navaid radial and/or DME
+// distance. Either value may be missing.
+type FixRadial struct {
+	NavID    string
+	NavType  string
+	Bearing  sql.NullFloat64 // degrees magnetic from the navaid
+	Distance sql.NullFloat64 // NM from the navaid
+}
+
+// ResolveFix returns every fix with the given FIX_ID. Fix idents are only
+// unique within an ICAO region, so all candidates are returned; when near is
+// given they are ordered nearest first. It returns ErrNotFound when no fix
+// has the ident.
+func ResolveFix(db *sql.DB, ident string, near *LatLon) ([]Fix, error) {
+	recs, err := queryRecords(db, `SELECT * FROM "FIX_BASE" WHERE "FIX_ID" = ? ORDER BY "ICAO_REGION_CODE"`, ident)
+	if err != nil {
+		return nil, fmt.Errorf("resolve fix %s: %w", ident, err)
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("fix %s: %w", ident, ErrNotFound)
+	}
+
+	fixes := make([]Fix, len(recs))
+	for i, r := range recs {
+		f := &fixes[i]
+		*f = Fix{
+			ID:        r.str("FIX_ID"),
+			Region:    r.str("ICAO_REGION_CODE"),
+			State:     r.str("STATE_CODE"),
+			Country:   r.str("COUNTRY_CODE"),
+			Lat:       r.float("LAT_DECIMAL").Float64,
+			Lon:       r.float("LONG_DECIMAL").Float64,
+			Use:       strings.TrimSpace(r.str("FIX_USE_CODE")),
+			ARTCCHigh: r.str("ARTCC_ID_HIGH"),
+			ARTCCLow:  r.str("ARTCC_ID_LOW"),
+		}
+		if near != nil {
+			f.DistanceNM = DistanceNM(near.Lat, near.Lon, f.Lat, f.Lon)
+		}
+
+		chrts, err := queryRecords(db, `SELECT "CHARTING_TYPE_DESC" FROM "FIX_CHRT"
+WHERE "FIX_ID" = ? AND "ICAO_REGION_CODE" = ? ORDER BY rowid`, f.ID, f.Region)
+		if err != nil {
+			return nil, fmt.Errorf("charts for %s: %w", f.ID, err)
+		}
+		for _, c := range chrts {
+			f.Charts = append(f.Charts, c.str("CHARTING_TYPE_DESC"))
+		}
+
+		navs, err := queryRecords(db, `SELECT * FROM "FIX_NAV"
+WHERE "FIX_ID" = ? AND "ICAO_REGION_CODE" = ? ORDER BY rowid`, f.ID, f.Region)
+		if err != nil {
+			return nil, fmt.Errorf("radials for %s: %w", f.ID, err)
+		}
+		for _, n := range navs {
+			f.Radials = append(f.Radials, FixRadial{
+				NavID:    n.str("NAV_ID"),
+				NavType:  n.str("NAV_TYPE"),
+				Bearing:  n.float("BEARING"),
+				Distance: n.float("DISTANCE"),
+			})
+		}
+	}
+
+	if near != nil {
+		sort.SliceStable(fixes, func(i, j int) bool { return fixes[i].DistanceNM < fixes[j].DistanceNM })
+	}
+	return fixes, nil
+}
+
+// RadialCheck compares one FixRadial with the fix's published position.
+type RadialCheck struct {
+	Radial FixRadial
+
+	// Navaid is the NAV_BASE navaid the radial refers to, or nil when it is
+	// not in the database. The other fields are only set when it was found.
+	Navaid *Navaid
+
+	Bearing       float64 // magnetic bearing from the navaid to the fix position
+	Distance      float64 // NM from the navaid to the fix position
+	BearingError  float64 // degrees between published and computed bearing
+	DistanceError float64 // NM between published and computed distance
+
+	Consistent bool
+}
+
+// Default tolerances used by CheckFixRadials.
+const (
+	RadialToleranceDeg = 1.0
+	DMEToleranceNM     = 0.5
+)
+
+// CheckFixRadials recomputes the bearing and distance from each defining
+// navaid to the fix's published latitude/longitude and compares them with
+// the FIX_NAV values. Bearings are converted to magnetic using the navaid's
+// magnetic variation. A radial is consistent when the bearing is within
+// RadialToleranceDeg and the distance within DMEToleranceNM; values that are
+// not published are not compared. Radials whose navaid cannot be found are
+// reported as inconsistent with a nil Navaid.
+func CheckFixRadials(db *sql.DB, f *Fix) ([]RadialCheck, error) {
+	checks := make([]RadialCheck, 0, len(f.Radials))
+	for _, rad := range f.Radials {
+		c := RadialCheck{Radial: rad}
+		navs, err := LookupNavaids(db, rad.NavID)
+		if err != nil {
+			return nil, err
+		}
+		var nav *Navaid
+		for i := range navs {
+			n := &navs[i]
+			if rad.NavType != "" && n.Type != rad.NavType {
+				continue
+			}
+			if nav == nil || DistanceNM(f.Lat, f.Lon, n.Lat, n.Lon) < DistanceNM(f.Lat, f.Lon, nav.Lat, nav.Lon) {
+				nav = n
+			}
+		}
+		if nav == nil {
+			checks = append(checks, c)
+			continue
+		}
+		c.Navaid = nav
+
+		// The DME antenna, when published separately, is the origin for
+		// distances; the navaid position is the origin for radials.
+		dmeLat, dmeLon := nav.Lat, nav.Lon
+		if nav.TACANLat.Valid && nav.TACANLon.Valid {
+			dmeLat, dmeLon = nav.TACANLat.Float64, nav.TACANLon.Float64
+		}
+		trueBrg := initialBearing(nav.Lat, nav.Lon, f.Lat, f.Lon)
+		c.Bearing = normalizeBearing(trueBrg - nav.MagVar.Float64)
+		c.Distance = DistanceNM(dmeLat, dmeLon, f.Lat, f.Lon)
+
+		c.Consistent = true
+		if rad.Bearing.Valid {
+			c.BearingError = angleDiff(c.Bearing, rad.Bearing.Float64)
+			if c.BearingError > RadialToleranceDeg {
+				c.Consistent = false
+			}
+		}
+		if rad.Distance.Valid {
+			c.DistanceError = c.Distance - rad.Distance.Float64
+			if c.DistanceError > DMEToleranceNM || c.DistanceError < -DMEToleranceNM {
+				c.Consistent = false
+			}
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+func normalizeBearing(b float64) float64 {
+	for b < 0 {
+		b += 360
+	}
+	for b >= 360 {
+		b -= 360
+	}
+	return b
+}
@@ -0,0 +1,116 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestInitialBearing(t *testing.T) {
+	tests := []struct {
+		lat1, lon1, lat2, lon2, want float64
+	}{
+		{0, 0, 1, 0, 0},
+		{0, 0, 0, 1, 90},
+		{0, 0, -1, 0, 180},
+		{0, 0, 0, -1, 270},
+		{0, 179.5, 0, -179.5, 90},
+	}
+	for _, tt := range tests {
+		got := initialBearing(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+		if angleDiff(got, tt.want) > 1e-9 {
+			t.Errorf("initialBearing(%v, %v, %v, %v) = %v, want %v", tt.lat1, tt.lon1, tt.lat2, tt.lon2, got, tt.want)
+		}
+	}
+	if d := angleDiff(359, 1); d != 2 {
+		t.Errorf("angleDiff(359, 1) = %v, want 2", d)
+	}
+}
+
+func TestResolveFix(t *testing.T) {
+	db := openTestDB(t)
+
+	fixes, err := ResolveFix(db, "HIKES", nil)
+	if err != nil {
+		t.Fatalf("ResolveFix(HIKES): %v", err)
+	}
+	if len(fixes) != 1 || len(fixes[0].Radials) == 0 || fixes[0].Radials[0].NavID != "PTW" {
+		t.Fatalf("HIKES = %+v", fixes)
+	}
+	checks, err := CheckFixRadials(db, &fixes[0])
+	if err != nil {
+		t.Fatalf("CheckFixRadials: %v", err)
+	}
+	if len(checks) == 0 || checks[0].Navaid == nil || !checks[0].Consistent {
+		t.Errorf("HIKES radial check = %+v", checks)
+	}
+
+	if _, err := ResolveFix(db, "NOFIX", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResolveFix(NOFIX) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestResolveFix_RegionsAndRadials(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	// NAV at 40N 100W with no variation. GOODY lies on its 090 radial at
+	// 60 NM; the BADDY radial is published 10 degrees off.
+	goodLat, goodLon := destination(40, -100, 90, 60)
+	stmts := []string{
+		`CREATE TABLE FIX_BASE (FIX_ID TEXT, ICAO_REGION_CODE TEXT, LAT_DECIMAL REAL, LONG_DECIMAL REAL)`,
+		`CREATE TABLE FIX_CHRT (FIX_ID TEXT, ICAO_REGION_CODE TEXT, CHARTING_TYPE_DESC TEXT)`,
+		`CREATE TABLE FIX_NAV (FIX_ID TEXT, ICAO_REGION_CODE TEXT, NAV_ID TEXT, NAV_TYPE TEXT, BEARING REAL, DISTANCE REAL)`,
+		`CREATE TABLE NAV_BASE (NAV_ID TEXT, NAV_TYPE TEXT, CITY TEXT, COUNTRY_CODE TEXT, LAT_DECIMAL REAL, LONG_DECIMAL REAL)`,
+		`CREATE TABLE NAV_CKPT (NAV_ID TEXT, NAV_TYPE TEXT, CITY TEXT, COUNTRY_CODE TEXT)`,
+		`CREATE TABLE NAV_RMK (NAV_ID TEXT, NAV_TYPE TEXT, CITY TEXT, COUNTRY_CODE TEXT)`,
+		`INSERT INTO NAV_BASE VALUES ('NAV', 'VORTAC', 'X', 'US', 40, -100)`,
+		`INSERT INTO FIX_BASE VALUES ('GOODY', 'K3', ?, ?), ('GOODY', 'PA', 61, -150), ('BADDY', 'K3', ?, ?)`,
+		`INSERT INTO FIX_CHRT VALUES ('GOODY', 'K3', 'IAP'), ('GOODY', 'K3', 'ENROUTE LOW')`,
+		`INSERT INTO FIX_NAV VALUES ('GOODY', 'K3', 'NAV', 'VORTAC', 90, 60), ('BADDY', 'K3', 'NAV', 'VORTAC', 80, NULL)`,
+	}
+	for _, s := range stmts {
+		var args []interface{}
+		if s == stmts[7] {
+			args = []interface{}{goodLat, goodLon, goodLat, goodLon}
+		}
+		if _, err := db.Exec(s, args...); err != nil {
+			t.Fatalf("exec: %v\n%s", err, s)
+		}
+	}
+
+	fixes, err := ResolveFix(db, "GOODY", &LatLon{62, -149})
+	if err != nil {
+		t.Fatalf("ResolveFix(GOODY): %v", err)
+	}
+	if len(fixes) != 2 || fixes[0].Region != "PA" || fixes[1].Region != "K3" {
+		t.Fatalf("GOODY candidates near Alaska = %+v", fixes)
+	}
+	k3 := fixes[1]
+	if len(k3.Charts) != 2 || k3.Charts[0] != "IAP" {
+		t.Errorf("GOODY charts = %v", k3.Charts)
+	}
+	checks, err := CheckFixRadials(db, &k3)
+	if err != nil {
+		t.Fatalf("CheckFixRadials(GOODY): %v", err)
+	}
+	if len(checks) != 1 || !checks[0].Consistent || math.Abs(checks[0].DistanceError) > 0.01 {
+		t.Errorf("GOODY check = %+v", checks)
+	}
+
+	bad, err := ResolveFix(db, "BADDY", nil)
+	if err != nil {
+		t.Fatalf("ResolveFix(BADDY): %v", err)
+	}
+	checks, err = CheckFixRadials(db, &bad[0])
+	if err != nil {
+		t.Fatalf("CheckFixRadials(BADDY): %v", err)
+	}
+	if len(checks) != 1 || checks[0].Consistent || math.Abs(checks[0].BearingError-10) > 0.5 {
+		t.Errorf("BADDY check = %+v, want inconsistent by about 10 degrees", checks)
+	}
+}
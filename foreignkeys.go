@@ -14,6 +14,10 @@ func foreignKeyDefs() []foreignKey {
 		{"APT_ATT", []string{"SITE_NO"}, "APT_BASE"},
 		{"APT_CON", []string{"SITE_NO"}, "APT_BASE"},
 		{"APT_RMK", []string{"SITE_NO"}, "APT_BASE"},
+		{"APT_FUEL", []string{"SITE_NO"}, "APT_BASE"},
+		{"APT_SVC", []string{"SITE_NO"}, "APT_BASE"},
+		{"APT_AFRM_RPR", []string{"SITE_NO"}, "APT_BASE"},
+		{"APT_OXY", []string{"SITE_NO"}, "APT_BASE"},
 		{"NAV_CKPT", []string{"NAV_ID", "NAV_TYPE", "CITY", "COUNTRY_CODE"}, "NAV_BASE"},
 		{"NAV_RMK", []string{"NAV_ID", "NAV_TYPE", "CITY", "COUNTRY_CODE"}, "NAV_BASE"},
 		{"FIX_CHRT", []string{"FIX_ID", "ICAO_REGION_CODE"}, "FIX_BASE"},
@@ -28,8 +32,10 @@ func foreignKeyDefs() []foreignKey {
 		{"ATC_RMK", []string{"FACILITY_ID", "FACILITY_TYPE"}, "ATC_BASE"},
 		{"STAR_APT", []string{"STAR_COMPUTER_CODE"}, "STAR_BASE"},
 		{"STAR_RTE", []string{"STAR_COMPUTER_CODE"}, "STAR_BASE"},
+		{"STAR_RTE_RWY", []string{"STAR_COMPUTER_CODE"}, "STAR_BASE"},
 		{"DP_APT", []string{"DP_COMPUTER_CODE"}, "DP_BASE"},
 		{"DP_RTE", []string{"DP_COMPUTER_CODE"}, "DP_BASE"},
+		{"DP_RTE_RWY", []string{"DP_COMPUTER_CODE"}, "DP_BASE"},
 		{"HPF_SPD_ALT", []string{"HP_NAME", "HP_NO"}, "HPF_BASE"},
 		{"HPF_CHRT", []string{"HP_NAME", "HP_NO"}, "HPF_BASE"},
 		{"HPF_RMK", []string{"HP_NAME", "HP_NO"}, "HPF_BASE"},
@@ -40,10 +46,12 @@ func foreignKeyDefs() []foreignKey {
 		{"MTR_TERR", []string{"ROUTE_TYPE_CODE", "ROUTE_ID"}, "MTR_BASE"},
 		{"MTR_WDTH", []string{"ROUTE_TYPE_CODE", "ROUTE_ID"}, "MTR_BASE"},
 		{"ARB_SEG", []string{"LOCATION_ID"}, "ARB_BASE"},
+		{"ARB_POLYGON", []string{"LOCATION_ID"}, "ARB_BASE"},
 		{"WXL_SVC", []string{"WEA_ID"}, "WXL_BASE"},
 		{"MAA_SHP", []string{"MAA_ID"}, "MAA_BASE"},
 		{"MAA_RMK", []string{"MAA_ID"}, "MAA_BASE"},
 		{"MAA_CON", []string{"MAA_ID"}, "MAA_BASE"},
+		{"MAA_ARPT", []string{"MAA_ID"}, "MAA_BASE"},
 		{"PJA_CON", []string{"PJA_ID"}, "PJA_BASE"},
 		{"FSS_RMK", []string{"FSS_ID"}, "FSS_BASE"},
 	}
@@ -0,0 +1,93 @@
+package nasr
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusNM is the mean Earth radius in nautical miles.
+const earthRadiusNM = 3440.065
+
+func deg2rad(d float64) float64 { return d * math.Pi / 180 }
+func rad2deg(r float64) float64 { return r * 180 / math.Pi }
+
+// DistanceNM returns the great-circle distance in nautical miles between two
+// points given in decimal degrees.
+func DistanceNM(lat1, lon1, lat2, lon2 float64) float64 {
+	p1, p2 := deg2rad(lat1), deg2rad(lat2)
+	dLat := p2 - p1
+	dLon := deg2rad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(p1)*math.Cos(p2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusNM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// destination returns the point reached by travelling distNM nautical miles
+// from (lat, lon) on the given true bearing, along a great circle.
+func destination(lat, lon, bearingDeg, distNM float64) (float64, float64) {
+	p1 := deg2rad(lat)
+	l1 := deg2rad(lon)
+	brg := deg2rad(bearingDeg)
+	d := distNM / earthRadiusNM
+	p2 := math.Asin(math.Sin(p1)*math.Cos(d) + math.Cos(p1)*math.Sin(d)*math.Cos(brg))
+	l2 := l1 + math.Atan2(math.Sin(brg)*math.Sin(d)*math.Cos(p1), math.Cos(d)-math.Sin(p1)*math.Sin(p2))
+	return rad2deg(p2), normalizeLon(rad2deg(l2))
+}
+
+// initialBearing returns the initial true bearing in degrees [0, 360) of the
+// great circle from the first point to the second.
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	p1, p2 := deg2rad(lat1), deg2rad(lat2)
+	dLon := deg2rad(lon2 - lon1)
+	y := math.Sin(dLon) * math.Cos(p2)
+	x := math.Cos(p1)*math.Sin(p2) - math.Sin(p1)*math.Cos(p2)*math.Cos(dLon)
+	return math.Mod(rad2deg(math.Atan2(y, x))+360, 360)
+}
+
+// angleDiff returns the absolute difference between two bearings in degrees,
+// in [0, 180].
+func angleDiff(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+func normalizeLon(lon float64) float64 {
+	lon = math.Mod(lon+540, 360) - 180
+	if lon == -180 {
+		return 180
+	}
+	return lon
+}
+
+// parseDMS parses the hyphenated degrees-minutes-seconds form used by the
+// text LATITUDE/LONGITUDE columns, e.g. "33-54-12.8500N" or "087-19-53.7600W".
+func parseDMS(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return 0, false
+	}
+	hemis := s[len(s)-1]
+	parts := strings.Split(s[:len(s)-1], "-")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	var v [3]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, false
+		}
+		v[i] = f
+	}
+	dec := v[0] + v[1]/60 + v[2]/3600
+	switch hemis {
+	case 'N', 'E':
+		return dec, true
+	case 'S', 'W':
+		return -dec, true
+	}
+	return 0, false
+}
@@ -0,0 +1,249 @@
+package nasr
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func geoJSONLayers() []layerDef {
+	return []layerDef{
+		{"airports", geomPoint, buildAirportGeoJSONLayer},
+		{"navaids", geomPoint, pointLayer("NAV_BASE")},
+		{"fixes", geomPoint, pointLayer("FIX_BASE")},
+		{"airways", geomLineString, buildAirwayLayer},
+		{"artcc", geomPolygon, buildARTCCLayer},
+		{"maa", geomPolygon, buildMAALayer},
+		{"pja", geomPolygon, buildPJALayer},
+	}
+}
+
+// GeoJSONLayers returns the layer names accepted by ExportGeoJSON.
+func GeoJSONLayers() []string {
+	defs := geoJSONLayers()
+	names := make([]string, len(defs))
+	for i, d := range defs {
+		names[i] = d.name
+	}
+	return names
+}
+
+// ExportGeoJSON writes one layer of a NASR database as a GeoJSON
+// FeatureCollection. Feature properties are the NASR columns of the source
+// table. The layers are:
+//
+//   - airports: APT_BASE points, with APT_RWY runways and their APT_RWY_END
+//     ends nested under a "runways" property
+//   - navaids: NAV_BASE points
+//   - fixes: FIX_BASE points
+//   - airways: AWY_BASE line strings through the AWY_SEG_ALT points in
+//     POINT_SEQ order
+//   - artcc: ARTCC boundary polygons from ARB_SEG
+//   - maa: MAA_BASE polygons from MAA_SHP, or circles of MAA_RADIUS
+//   - pja: PJA_BASE circles of PJA_RADIUS, or points when no radius is given
+func ExportGeoJSON(db *sql.DB, w io.Writer, layerName string) error {
+	var def *layerDef
+	for _, d := range geoJSONLayers() {
+		if d.name == layerName {
+			def = &d
+			break
+		}
+	}
+	if def == nil {
+		return fmt.Errorf("unknown GeoJSON layer %q (want one of %s)", layerName, strings.Join(GeoJSONLayers(), ", "))
+	}
+
+	layers, err := buildLayers(db, []layerDef{*def})
+	if err != nil {
+		return err
+	}
+	return writeFeatureCollection(w, layers[0])
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func (g geometry) geoJSON() geoJSONGeometry {
+	pos := func(c coord) [2]float64 { return [2]float64{c.lon, c.lat} }
+	ring := func(cs []coord) [][2]float64 {
+		out := make([][2]float64, len(cs))
+		for i, c := range cs {
+			out[i] = pos(c)
+		}
+		return out
+	}
+	switch g.kind {
+	case geomLineString:
+		return geoJSONGeometry{"LineString", ring(g.rings[0])}
+	case geomPolygon:
+		rings := make([][][2]float64, len(g.rings))
+		for i, r := range g.rings {
+			rings[i] = ring(r)
+		}
+		return geoJSONGeometry{"Polygon", rings}
+	}
+	return geoJSONGeometry{"Point", pos(g.rings[0][0])}
+}
+
+// writeFeatureCollection streams a layer as a FeatureCollection, one feature
+// per line.
+func writeFeatureCollection(w io.Writer, l *layer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `{"type":"FeatureCollection","name":%q,"features":[`, l.name)
+	enc := json.NewEncoder(bw)
+	for i, f := range l.features {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteByte('\n')
+		props := make(map[string]interface{}, len(l.columns))
+		for j, c := range l.columns {
+			props[c.name] = f.values[j]
+		}
+		gf := geoJSONFeature{Type: "Feature", Geometry: f.geom.geoJSON(), Properties: props}
+		if err := enc.Encode(gf); err != nil {
+			return fmt.Errorf("encode feature: %w", err)
+		}
+	}
+	bw.WriteString("]}\n")
+	return bw.Flush()
+}
+
+// rowMap turns a row into a column name to value map.
+func rowMap(cols []layerColumn, row []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(cols))
+	for i, c := range cols {
+		m[c.name] = row[i]
+	}
+	return m
+}
+
+// buildAirportGeoJSONLayer is the APT_BASE point layer with each airport's
+// runways, and each runway's ends, nested in a "runways" column.
+func buildAirportGeoJSONLayer(db *sql.DB, l *layer) error {
+	if err := pointLayer("APT_BASE")(db, l); err != nil {
+		return err
+	}
+
+	rwyCols, rwys, err := queryRows(db, `SELECT * FROM "APT_RWY" ORDER BY "SITE_NO", "RWY_ID"`)
+	if err != nil {
+		return err
+	}
+	endCols, ends, err := queryRows(db, `SELECT * FROM "APT_RWY_END" ORDER BY "SITE_NO", "RWY_ID", "RWY_END_ID"`)
+	if err != nil {
+		return err
+	}
+
+	type rwyKey struct{ site, rwy string }
+	endsByRwy := make(map[rwyKey][]map[string]interface{})
+	siteIdx, rwyIdx := columnIndex(endCols, "SITE_NO"), columnIndex(endCols, "RWY_ID")
+	for _, e := range ends {
+		k := rwyKey{asString(e[siteIdx]), asString(e[rwyIdx])}
+		endsByRwy[k] = append(endsByRwy[k], rowMap(endCols, e))
+	}
+
+	rwysBySite := make(map[string][]map[string]interface{})
+	siteIdx, rwyIdx = columnIndex(rwyCols, "SITE_NO"), columnIndex(rwyCols, "RWY_ID")
+	for _, r := range rwys {
+		m := rowMap(rwyCols, r)
+		k := rwyKey{asString(r[siteIdx]), asString(r[rwyIdx])}
+		if e := endsByRwy[k]; e != nil {
+			m["ends"] = e
+		} else {
+			m["ends"] = []map[string]interface{}{}
+		}
+		rwysBySite[k.site] = append(rwysBySite[k.site], m)
+	}
+
+	siteIdx = columnIndex(l.columns, "SITE_NO")
+	l.columns = append(l.columns, layerColumn{"runways", "JSON"})
+	for i := range l.features {
+		f := &l.features[i]
+		runways := rwysBySite[asString(f.values[siteIdx])]
+		if runways == nil {
+			runways = []map[string]interface{}{}
+		}
+		f.values = append(f.values, runways)
+	}
+	return nil
+}
+
+// buildAirwayLayer draws each AWY_BASE airway as one line string through its
+// AWY_SEG_ALT FROM_POINTs in POINT_SEQ order, ending at the last TO_POINT.
+// Points that cannot be resolved against FIX_BASE or NAV_BASE are skipped.
+func buildAirwayLayer(db *sql.DB, l *layer) error {
+	idx, err := loadPointIndex(db)
+	if err != nil {
+		return err
+	}
+	cols, rows, err := queryRows(db, `SELECT * FROM "AWY_BASE" ORDER BY "AWY_LOCATION", "AWY_ID"`)
+	if err != nil {
+		return err
+	}
+	l.columns = cols
+	idIdx, locIdx := columnIndex(cols, "AWY_ID"), columnIndex(cols, "AWY_LOCATION")
+	for _, r := range rows {
+		_, segs, err := queryRows(db, `SELECT "FROM_POINT", "FROM_PT_TYPE", "ICAO_REGION_CODE", "TO_POINT"
+FROM "AWY_SEG_ALT" WHERE "AWY_ID" = ? AND "AWY_LOCATION" = ? ORDER BY "POINT_SEQ"`, r[idIdx], r[locIdx])
+		if err != nil {
+			return err
+		}
+		var line []coord
+		var prev *namedPoint
+		add := func(ident, region string, kind pointKind) {
+			p, ok := idx.resolve(ident, region, kind, prev)
+			if !ok {
+				return
+			}
+			line = append(line, coord{p.lon, p.lat})
+			prev = &p
+		}
+		for _, s := range segs {
+			add(asString(s[0]), asString(s[2]), pointKindOf(asString(s[1])))
+		}
+		if n := len(segs); n > 0 && asString(segs[n-1][3]) != "" {
+			add(asString(segs[n-1][3]), "", pointAny)
+		}
+		if len(line) < 2 {
+			continue
+		}
+		l.features = append(l.features, feature{lineGeom(line), r})
+	}
+	return nil
+}
+
+// buildPJALayer draws each PJA_BASE area as a circle of PJA_RADIUS, or as a
+// point when the radius is not given.
+func buildPJALayer(db *sql.DB, l *layer) error {
+	cols, rows, err := queryRows(db, `SELECT * FROM "PJA_BASE"`)
+	if err != nil {
+		return err
+	}
+	l.columns = cols
+	latIdx, lonIdx := columnIndex(cols, "LAT_DECIMAL"), columnIndex(cols, "LONG_DECIMAL")
+	radiusIdx := columnIndex(cols, "PJA_RADIUS")
+	for _, r := range rows {
+		lat, ok1 := asFloat(r[latIdx])
+		lon, ok2 := asFloat(r[lonIdx])
+		if !ok1 || !ok2 {
+			continue
+		}
+		if radius, ok := asFloat(r[radiusIdx]); ok && radius > 0 {
+			l.features = append(l.features, feature{circleGeom(lat, lon, radius), r})
+		} else {
+			l.features = append(l.features, feature{pointGeom(lat, lon), r})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package nasr
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type testFeatureCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"features"`
+}
+
+func exportTestLayer(t *testing.T, layer string) testFeatureCollection {
+	t.Helper()
+	db := openTestDB(t)
+	var buf bytes.Buffer
+	if err := ExportGeoJSON(db, &buf, layer); err != nil {
+		t.Fatalf("ExportGeoJSON(%s): %v", layer, err)
+	}
+	var fc testFeatureCollection
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("decode %s: %v", layer, err)
+	}
+	if fc.Type != "FeatureCollection" {
+		t.Errorf("type = %q, want FeatureCollection", fc.Type)
+	}
+	return fc
+}
+
+func TestExportGeoJSON_Airports(t *testing.T) {
+	fc := exportTestLayer(t, "airports")
+	for _, f := range fc.Features {
+		if f.Properties["SITE_NO"] != "00103." {
+			continue
+		}
+		if f.Geometry.Type != "Point" {
+			t.Errorf("geometry type = %q, want Point", f.Geometry.Type)
+		}
+		runways, ok := f.Properties["runways"].([]interface{})
+		if !ok || len(runways) != 1 {
+			t.Fatalf("runways = %v, want one runway", f.Properties["runways"])
+		}
+		rwy := runways[0].(map[string]interface{})
+		if rwy["RWY_ID"] != "18/36" {
+			t.Errorf("RWY_ID = %v, want 18/36", rwy["RWY_ID"])
+		}
+		if ends, _ := rwy["ends"].([]interface{}); len(ends) != 1 {
+			t.Errorf("ends = %v, want one runway end", rwy["ends"])
+		}
+		return
+	}
+	t.Error("airport 0J0 not found")
+}
+
+func TestExportGeoJSON_PJA(t *testing.T) {
+	fc := exportTestLayer(t, "pja")
+	polygons := 0
+	for _, f := range fc.Features {
+		if f.Geometry.Type != "Polygon" {
+			continue
+		}
+		polygons++
+		var rings [][][2]float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &rings); err != nil {
+			t.Fatalf("decode polygon: %v", err)
+		}
+		if r := rings[0]; r[0] != r[len(r)-1] {
+			t.Errorf("polygon ring for %v is not closed", f.Properties["PJA_ID"])
+		}
+	}
+	if polygons == 0 {
+		t.Error("expected PJA circles with PJA_RADIUS to be polygons")
+	}
+}
+
+func TestExportGeoJSON_UnknownLayer(t *testing.T) {
+	db := openTestDB(t)
+	if err := ExportGeoJSON(db, &bytes.Buffer{}, "nope"); err == nil {
+		t.Error("expected error for unknown layer")
+	}
+}
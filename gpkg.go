@@ -0,0 +1,207 @@
+package nasr
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GeoPackage constants from OGC 12-128r18 (GeoPackage 1.3).
+const (
+	gpkgApplicationID = 0x47504B47 // "GPKG"
+	gpkgUserVersion   = 10300
+	gpkgSRSID         = 4326 // WGS 84 geographic 2D
+)
+
+var gpkgCoreDDL = []string{
+	`CREATE TABLE gpkg_spatial_ref_sys (
+  srs_name TEXT NOT NULL,
+  srs_id INTEGER NOT NULL PRIMARY KEY,
+  organization TEXT NOT NULL,
+  organization_coordsys_id INTEGER NOT NULL,
+  definition TEXT NOT NULL,
+  description TEXT
+);`,
+	`CREATE TABLE gpkg_contents (
+  table_name TEXT NOT NULL PRIMARY KEY,
+  data_type TEXT NOT NULL,
+  identifier TEXT UNIQUE,
+  description TEXT DEFAULT '',
+  last_change DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ','now')),
+  min_x DOUBLE,
+  min_y DOUBLE,
+  max_x DOUBLE,
+  max_y DOUBLE,
+  srs_id INTEGER,
+  CONSTRAINT fk_gc_r_srs_id FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys(srs_id)
+);`,
+	`CREATE TABLE gpkg_geometry_columns (
+  table_name TEXT NOT NULL,
+  column_name TEXT NOT NULL,
+  geometry_type_name TEXT NOT NULL,
+  srs_id INTEGER NOT NULL,
+  z TINYINT NOT NULL,
+  m TINYINT NOT NULL,
+  CONSTRAINT pk_geom_cols PRIMARY KEY (table_name, column_name),
+  CONSTRAINT uk_gc_table_name UNIQUE (table_name),
+  CONSTRAINT fk_gc_tn FOREIGN KEY (table_name) REFERENCES gpkg_contents(table_name),
+  CONSTRAINT fk_gc_srs FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys (srs_id)
+);`,
+	`INSERT INTO gpkg_spatial_ref_sys VALUES
+  ('Undefined cartesian SRS', -1, 'NONE', -1, 'undefined', 'undefined cartesian coordinate reference system'),
+  ('Undefined geographic SRS', 0, 'NONE', 0, 'undefined', 'undefined geographic coordinate reference system'),
+  ('WGS 84 geodetic', 4326, 'EPSG', 4326,
+   'GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]',
+   'longitude/latitude coordinates in decimal degrees on the WGS 84 spheroid');`,
+}
+
+func (k geomKind) gpkgName() string {
+	switch k {
+	case geomLineString:
+		return "LINESTRING"
+	case geomPolygon:
+		return "POLYGON"
+	}
+	return "POINT"
+}
+
+// writeGeoPackage turns the database into an OGC GeoPackage: it creates the
+// required metadata tables and one feature table per layer. The NASR tables
+// are left in place alongside the layers.
+func writeGeoPackage(db *sql.DB) error {
+	layers, err := buildLayers(db, geoPackageLayers())
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range gpkgCoreDDL {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("create GeoPackage metadata: %w\n%s", err, stmt)
+		}
+	}
+	for _, l := range layers {
+		if err := writeGeoPackageLayer(tx, l); err != nil {
+			return fmt.Errorf("write layer %s: %w", l.name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA application_id = %d", gpkgApplicationID)); err != nil {
+		return fmt.Errorf("set application_id: %w", err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", gpkgUserVersion)); err != nil {
+		return fmt.Errorf("set user_version: %w", err)
+	}
+	return nil
+}
+
+func writeGeoPackageLayer(tx *sql.Tx, l *layer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %q (\n  \"fid\" INTEGER PRIMARY KEY AUTOINCREMENT,\n  \"geom\" %s", l.name, l.kind.gpkgName())
+	for _, c := range l.columns {
+		fmt.Fprintf(&b, ",\n  %q %s", c.name, c.dataType)
+	}
+	b.WriteString("\n);")
+	if _, err := tx.Exec(b.String()); err != nil {
+		return fmt.Errorf("%w\n%s", err, b.String())
+	}
+
+	placeholders := make([]string, len(l.columns)+1)
+	quoted := make([]string, len(l.columns)+1)
+	quoted[0] = `"geom"`
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	for i, c := range l.columns {
+		quoted[i+1] = fmt.Sprintf("%q", c.name)
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %q (%s) VALUES (%s)",
+		l.name, strings.Join(quoted, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, f := range l.features {
+		args := append([]interface{}{gpkgGeometry(f.geom)}, f.values...)
+		if _, err := stmt.Exec(args...); err != nil {
+			return err
+		}
+		x0, x1, y0, y1 := f.geom.envelope()
+		minX, maxX = math.Min(minX, x0), math.Max(maxX, x1)
+		minY, maxY = math.Min(minY, y0), math.Max(maxY, y1)
+	}
+
+	var extent []interface{}
+	if len(l.features) > 0 {
+		extent = []interface{}{minX, minY, maxX, maxY}
+	} else {
+		extent = []interface{}{nil, nil, nil, nil}
+	}
+	_, err = tx.Exec(`INSERT INTO gpkg_contents (table_name, data_type, identifier, min_x, min_y, max_x, max_y, srs_id)
+VALUES (?, 'features', ?, ?, ?, ?, ?, ?)`, append(append([]interface{}{l.name, l.name}, extent...), gpkgSRSID)...)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO gpkg_geometry_columns VALUES (?, 'geom', ?, ?, 0, 0)`,
+		l.name, l.kind.gpkgName(), gpkgSRSID)
+	return err
+}
+
+// gpkgGeometry encodes a geometry as a GeoPackage binary blob: the "GP"
+// header with SRS id and envelope, followed by little-endian WKB.
+func gpkgGeometry(g geometry) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+
+	buf.WriteString("GP")
+	buf.WriteByte(0) // version 1
+	flags := byte(0x01)
+	if g.kind != geomPoint {
+		flags |= 1 << 1 // envelope is [minx, maxx, miny, maxy]
+	}
+	buf.WriteByte(flags)
+	binary.Write(&buf, le, int32(gpkgSRSID))
+	if g.kind != geomPoint {
+		x0, x1, y0, y1 := g.envelope()
+		binary.Write(&buf, le, [4]float64{x0, x1, y0, y1})
+	}
+
+	buf.WriteByte(1) // WKB little endian
+	switch g.kind {
+	case geomPoint:
+		binary.Write(&buf, le, uint32(1))
+		c := g.rings[0][0]
+		binary.Write(&buf, le, [2]float64{c.lon, c.lat})
+	case geomLineString:
+		binary.Write(&buf, le, uint32(2))
+		writeWKBRing(&buf, g.rings[0])
+	case geomPolygon:
+		binary.Write(&buf, le, uint32(3))
+		binary.Write(&buf, le, uint32(len(g.rings)))
+		for _, r := range g.rings {
+			writeWKBRing(&buf, r)
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeWKBRing(buf *bytes.Buffer, cs []coord) {
+	binary.Write(buf, binary.LittleEndian, uint32(len(cs)))
+	for _, c := range cs {
+		binary.Write(buf, binary.LittleEndian, [2]float64{c.lon, c.lat})
+	}
+}
@@ -0,0 +1,138 @@
+package nasr
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDMS(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"33-54-12.8500N", 33.903569, true},
+		{"087-19-53.7600W", -87.331600, true},
+		{"70-11-41.1200S", -70.194756, true},
+		{"", 0, false},
+		{"33-54N", 0, false},
+		{"33-54-12.85X", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDMS(tt.in)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-5 {
+			t.Errorf("parseDMS(%q) = %f, %v; want %f, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGPKGGeometry(t *testing.T) {
+	b := gpkgGeometry(lineGeom([]coord{{-116, 43}, {-115, 44}}))
+	if string(b[:2]) != "GP" {
+		t.Fatalf("magic = %q, want GP", b[:2])
+	}
+	if srs := binary.LittleEndian.Uint32(b[4:8]); srs != gpkgSRSID {
+		t.Errorf("srs_id = %d, want %d", srs, gpkgSRSID)
+	}
+	// Header (8) + xy envelope (32) + byte order (1) + type (4) + count (4) + 2 points (32).
+	if len(b) != 81 {
+		t.Errorf("blob length = %d, want 81", len(b))
+	}
+	if typ := binary.LittleEndian.Uint32(b[41:45]); typ != 2 {
+		t.Errorf("WKB type = %d, want 2 (LineString)", typ)
+	}
+}
+
+func TestExtract_GeoPackage(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "nasr.gpkg")
+	if err := Extract(testZipPath, dbPath, WithGeoPackage()); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	var appID, version int
+	if err := db.QueryRow("PRAGMA application_id").Scan(&appID); err != nil {
+		t.Fatalf("application_id: %v", err)
+	}
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("user_version: %v", err)
+	}
+	if appID != gpkgApplicationID || version != gpkgUserVersion {
+		t.Errorf("application_id=%#x user_version=%d, want GPKG 1.3", appID, version)
+	}
+
+	var layers int
+	if err := db.QueryRow("SELECT count(*) FROM gpkg_contents WHERE data_type = 'features'").Scan(&layers); err != nil {
+		t.Fatalf("gpkg_contents: %v", err)
+	}
+	if layers != len(geoPackageLayers()) {
+		t.Errorf("expected %d feature layers, got %d", len(geoPackageLayers()), layers)
+	}
+
+	var geom []byte
+	var name string
+	err = db.QueryRow(`SELECT geom, ARPT_NAME FROM airports WHERE ARPT_ID = 'BOI'`).Scan(&geom, &name)
+	if err != nil {
+		t.Fatalf("query airports: %v", err)
+	}
+	if name != "BOISE AIR TRML/GOWEN FLD" {
+		t.Errorf("ARPT_NAME = %q", name)
+	}
+	lon := math.Float64frombits(binary.LittleEndian.Uint64(geom[13:21]))
+	lat := math.Float64frombits(binary.LittleEndian.Uint64(geom[21:29]))
+	if math.Abs(lat-43.56) > 0.1 || math.Abs(lon+116.22) > 0.1 {
+		t.Errorf("BOI point = (%f, %f), want ~(-116.22, 43.56)", lon, lat)
+	}
+
+	var fixes, located int
+	if err := db.QueryRow("SELECT count(*) FROM fixes").Scan(&fixes); err != nil {
+		t.Fatalf("fixes: %v", err)
+	}
+	err = db.QueryRow(`SELECT count(*) FROM FIX_BASE
+WHERE typeof(LAT_DECIMAL) = 'real' AND typeof(LONG_DECIMAL) = 'real'`).Scan(&located)
+	if err != nil {
+		t.Fatalf("FIX_BASE: %v", err)
+	}
+	if fixes == 0 || fixes != located {
+		t.Errorf("fixes layer has %d features, want %d", fixes, located)
+	}
+}
+
+func TestPointIndexResolve(t *testing.T) {
+	idx := &pointIndex{
+		fixes: map[string][]namedPoint{
+			"ALPHA": {
+				{ident: "ALPHA", region: "K1", lat: 47, lon: -122},
+				{ident: "ALPHA", region: "K7", lat: 28, lon: -81},
+			},
+			"BOI": {{ident: "BOI", region: "K1", lat: 10, lon: 10}},
+		},
+		navaids: map[string][]namedPoint{
+			"BOI": {{ident: "BOI", navType: "VORTAC", lat: 43.55, lon: -116.19}},
+		},
+	}
+
+	if p, ok := idx.resolve("ALPHA", "K7", pointFix, nil); !ok || p.lat != 28 {
+		t.Errorf("region K7 resolved to %+v", p)
+	}
+	near := namedPoint{lat: 46, lon: -121}
+	if p, ok := idx.resolve("ALPHA", "", pointAny, &near); !ok || p.region != "K1" {
+		t.Errorf("nearest ALPHA resolved to %+v", p)
+	}
+	if p, ok := idx.resolve("BOI", "", pointKindOf("VORTAC"), nil); !ok || p.navType != "VORTAC" {
+		t.Errorf("navaid BOI resolved to %+v", p)
+	}
+	if p, ok := idx.resolve("BOI", "", pointKindOf("WP   "), nil); !ok || p.navType != "" {
+		t.Errorf("fix BOI resolved to %+v", p)
+	}
+	if _, ok := idx.resolve("NONE", "", pointAny, nil); ok {
+		t.Error("expected unknown ident to fail")
+	}
+}
@@ -0,0 +1,295 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IdahoAvionics/go-nasr/cycle"
+)
+
+// historyRowID is the alias under which appendTable selects a history row's
+// rowid alongside its columns.
+const historyRowID = "HISTORY_ROWID"
+
+// Append loads a subscription into the history database at historyPath,
+// creating it if it does not exist. A history database has every FAA table
+// of a database built by Extract, without keys or indexes, plus VALID_FROM
+// and VALID_TO columns holding the first and last cycle ("2602") in which
+// each version of a row was published. NASR_CYCLE has a row per appended
+// cycle.
+//
+// Rows are matched with the previous cycle's by natural key, as in Diff. A
+// row that did not change has its VALID_TO extended to the new cycle; a
+// modified or added row is inserted as a new version valid from the new
+// cycle, and the versions of modified and removed rows keep their VALID_TO.
+// Cycles must be appended in order. A skipped cycle is treated as unchanged
+// from the one before it.
+func Append(nasrSubscription, historyPath string) error {
+	if _, err := os.Stat(nasrSubscription); err != nil {
+		return fmt.Errorf("input file: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "nasr-append-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := Extract(nasrSubscription, filepath.Join(dir, "cycle.db")); err != nil {
+		return err
+	}
+	src, err := sql.Open("sqlite", filepath.Join(dir, "cycle.db"))
+	if err != nil {
+		return fmt.Errorf("open extracted database: %w", err)
+	}
+	defer src.Close()
+	c, err := DatabaseCycle(src)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%s: the file names do not give the AIRAC cycle", nasrSubscription)
+	}
+	if err != nil {
+		return err
+	}
+
+	hist, err := sql.Open("sqlite", historyPath)
+	if err != nil {
+		return fmt.Errorf("open history database: %w", err)
+	}
+	defer hist.Close()
+	return appendCycle(hist, src, c)
+}
+
+// appendCycle adds the cycle c, extracted to src, to a history database.
+func appendCycle(hist, src *sql.DB, c cycle.Cycle) error {
+	tx, err := hist.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS "NASR_CYCLE" (
+		"CYCLE" TEXT NOT NULL, "EFFECTIVE_DATE" TEXT, "EXPIRATION_DATE" TEXT, "SOURCE" TEXT)`); err != nil {
+		return fmt.Errorf("create NASR_CYCLE: %w", err)
+	}
+	var latest sql.NullString
+	if err := tx.QueryRow(`SELECT max("CYCLE") FROM "NASR_CYCLE"`).Scan(&latest); err != nil {
+		return fmt.Errorf("latest cycle: %w", err)
+	}
+	if latest.Valid && c.ID() <= latest.String {
+		return fmt.Errorf("cycle %s is not after %s, the latest in the history database", c, latest.String)
+	}
+
+	keys := naturalKeys()
+	tables := make([]string, 0, len(keys))
+	for t := range keys {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		if err := appendTable(tx, src, table, keys[table], c.ID(), latest); err != nil {
+			return fmt.Errorf("append %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO "NASR_CYCLE" VALUES (?, ?, ?, ?)`,
+		c.ID(), c.Effective().Format(effDateLayout), c.Expires().Format(effDateLayout), sourceOf(src)); err != nil {
+		return fmt.Errorf("record cycle: %w", err)
+	}
+	return tx.Commit()
+}
+
+// sourceOf returns the SOURCE recorded in an extracted database's
+// NASR_CYCLE, or nil.
+func sourceOf(db *sql.DB) interface{} {
+	var source sql.NullString
+	db.QueryRow(`SELECT "SOURCE" FROM "NASR_CYCLE" LIMIT 1`).Scan(&source)
+	if !source.Valid {
+		return nil
+	}
+	return source.String
+}
+
+// tableColumns returns the names and declared types of a table's columns.
+func tableColumns(db queryer, table string) (names, types []string, err error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%q)`, table))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+		types = append(types, typ)
+	}
+	return names, types, rows.Err()
+}
+
+// appendTable brings one history table up to cycle id. latest is the
+// previous cycle in the history database, if any.
+func appendTable(tx *sql.Tx, src *sql.DB, table string, keyCols []string, id string, latest sql.NullString) error {
+	names, types, err := tableColumns(src, table)
+	if err != nil {
+		return err
+	}
+	histNames, _, err := tableColumns(tx, table)
+	if err != nil {
+		return err
+	}
+	if names == nil {
+		return nil // dropped from this cycle: the current versions end
+	}
+
+	// Create the table, or add columns that first appear in this cycle.
+	if histNames == nil {
+		var b strings.Builder
+		fmt.Fprintf(&b, "CREATE TABLE %q (\n", table)
+		for i, name := range names {
+			fmt.Fprintf(&b, "  %q %s,\n", name, types[i])
+		}
+		b.WriteString("  \"VALID_FROM\" TEXT NOT NULL,\n  \"VALID_TO\" TEXT NOT NULL\n);")
+		if _, err := tx.Exec(b.String()); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`CREATE INDEX %q ON %q ("VALID_TO")`, "idx_"+table+"_VALID_TO", table)); err != nil {
+			return err
+		}
+	} else {
+		if !containsString(histNames, "VALID_TO") {
+			return fmt.Errorf("not a history database: %s has no VALID_TO column", table)
+		}
+		for i, name := range names {
+			if !containsString(histNames, name) {
+				if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %q ADD COLUMN %q %s`, table, name, types[i])); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	old := &diffRows{keys: map[string]RowKey{}, groups: map[string][]map[string]interface{}{}}
+	if latest.Valid {
+		old, err = loadDiffRows(tx, keyCols, fmt.Sprintf(`SELECT rowid AS %q, * FROM %q WHERE "VALID_TO" = ? ORDER BY rowid`, historyRowID, table), latest.String)
+		if err != nil {
+			return err
+		}
+	}
+	cur, err := loadTableRows(src, table, keyCols)
+	if err != nil {
+		return err
+	}
+	cols := sharedColumns(old.cols, names)
+
+	// Extend every current row, then end the versions that did not survive.
+	if latest.Valid {
+		if _, err := tx.Exec(fmt.Sprintf(`UPDATE %q SET "VALID_TO" = ? WHERE "VALID_TO" = ?`, table), id, latest.String); err != nil {
+			return err
+		}
+	}
+	end, err := tx.Prepare(fmt.Sprintf(`UPDATE %q SET "VALID_TO" = ? WHERE rowid = ?`, table))
+	if err != nil {
+		return err
+	}
+	defer end.Close()
+
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	insert, err := tx.Prepare(fmt.Sprintf(`INSERT INTO %q (%s, "VALID_FROM", "VALID_TO") VALUES (%s?, ?)`,
+		table, strings.Join(quoted, ", "), strings.Repeat("?, ", len(names))))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	matchRows(old, cur, func(key RowKey, o, n map[string]interface{}) {
+		if err != nil {
+			return
+		}
+		if o != nil && n != nil && changedColumns(cols, o, n) == nil {
+			return
+		}
+		if o != nil {
+			_, err = end.Exec(latest.String, o[historyRowID])
+		}
+		if n != nil && err == nil {
+			args := make([]interface{}, 0, len(names)+2)
+			for _, name := range names {
+				args = append(args, n[name])
+			}
+			_, err = insert.Exec(append(args, id, id)...)
+		}
+	})
+	return err
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AsOf creates views of a history database's tables as they were published
+// at date, and returns the cycle they show: the latest appended cycle in
+// effect at or before date. Each view is named <TABLE>_ASOF_<CYCLE>, e.g.
+// APT_RWY_ASOF_2503, and has the table's columns without VALID_FROM and
+// VALID_TO. The error wraps ErrNotFound when date is before the first
+// appended cycle or after the last one.
+func AsOf(db *sql.DB, date time.Time) (cycle.Cycle, error) {
+	want := cycle.At(date)
+	var id, latest sql.NullString
+	err := db.QueryRow(`SELECT (SELECT max("CYCLE") FROM "NASR_CYCLE" WHERE "CYCLE" <= ?), max("CYCLE") FROM "NASR_CYCLE"`, want.ID()).Scan(&id, &latest)
+	if err != nil {
+		return cycle.Cycle{}, fmt.Errorf("as of %s: %w", date.Format("2006-01-02"), err)
+	}
+	if !id.Valid || want.ID() > latest.String {
+		return cycle.Cycle{}, fmt.Errorf("as of %s: cycle %s: %w", date.Format("2006-01-02"), want, ErrNotFound)
+	}
+	c, err := cycle.Parse(id.String)
+	if err != nil {
+		return cycle.Cycle{}, err
+	}
+
+	keys := naturalKeys()
+	tables := make([]string, 0, len(keys))
+	for t := range keys {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		names, _, err := tableColumns(db, table)
+		if err != nil {
+			return cycle.Cycle{}, err
+		}
+		if names == nil {
+			continue
+		}
+		var cols []string
+		for _, name := range names {
+			if name != "VALID_FROM" && name != "VALID_TO" {
+				cols = append(cols, fmt.Sprintf("%q", name))
+			}
+		}
+		// The cycle is a validated four-digit ID, safe to inline in the view.
+		view := fmt.Sprintf("%s_ASOF_%s", table, c.ID())
+		stmt := fmt.Sprintf(`CREATE VIEW IF NOT EXISTS %q AS SELECT %s FROM %q WHERE "VALID_FROM" <= '%s' AND "VALID_TO" >= '%s'`,
+			view, strings.Join(cols, ", "), table, c.ID(), c.ID())
+		if _, err := db.Exec(stmt); err != nil {
+			return cycle.Cycle{}, fmt.Errorf("create view %s: %w", view, err)
+		}
+	}
+	return c, nil
+}
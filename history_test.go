@@ -0,0 +1,143 @@
+package nasr
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSubscription writes a copy of the test subscription with its inner
+// zip renamed to innerName and edit applied to each file in it.
+func writeSubscription(t *testing.T, innerName string, edit func(name string, data []byte) []byte) string {
+	t.Helper()
+	_, data, _, err := openInnerCSVZip(testZipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inner bytes.Buffer
+	zw := zip.NewWriter(&inner)
+	for _, f := range in.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(edit(f.Name, b))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "subscription.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	zw = zip.NewWriter(out)
+	w, err := zw.Create("CSV_Data/" + innerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(inner.Bytes())
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAppend(t *testing.T) {
+	// 2603 renames an airport, drops an AWOS and republishes every row.
+	next := writeSubscription(t, "19_Mar_2026_CSV.zip", func(name string, data []byte) []byte {
+		data = bytes.ReplaceAll(data, []byte("2026/02/19"), []byte("2026/03/19"))
+		switch name {
+		case "APT_BASE.csv":
+			data = bytes.Replace(data, []byte("ABBEVILLE MUNI"), []byte("ABBEVILLE RGNL"), 1)
+		case "AWOS.csv":
+			lines := bytes.SplitAfter(data, []byte("\n"))
+			data = bytes.Join(append(lines[:1:1], lines[2:]...), nil)
+		}
+		return data
+	})
+
+	path := filepath.Join(t.TempDir(), "history.db")
+	if err := Append(testZipPath, path); err != nil {
+		t.Fatalf("Append(2602): %v", err)
+	}
+	if err := Append(next, path); err != nil {
+		t.Fatalf("Append(2603): %v", err)
+	}
+	if err := Append(testZipPath, path); err == nil {
+		t.Error("Append(2602) after 2603: want error")
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if c, err := DatabaseCycle(db); err != nil || c.ID() != "2603" {
+		t.Errorf("DatabaseCycle = %v, %v; want 2603", c, err)
+	}
+
+	var versions, total, extended int
+	db.QueryRow(`SELECT count(*) FROM APT_BASE WHERE ARPT_ID = '0J0'`).Scan(&versions)
+	db.QueryRow(`SELECT count(*), sum(VALID_FROM = '2602' AND VALID_TO = '2603') FROM APT_BASE`).Scan(&total, &extended)
+	if versions != 2 || extended != total-2 {
+		t.Errorf("APT_BASE: %d versions of 0J0, %d of %d rows extended", versions, extended, total)
+	}
+	var validTo string
+	db.QueryRow(`SELECT VALID_TO FROM AWOS WHERE ASOS_AWOS_ID = '00U'`).Scan(&validTo)
+	if validTo != "2602" {
+		t.Errorf("removed AWOS 00U valid to %q, want 2602", validTo)
+	}
+
+	for _, tt := range []struct {
+		date  time.Time
+		cycle string
+		name  string
+	}{
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "2602", "ABBEVILLE MUNI"},
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), "2603", "ABBEVILLE RGNL"},
+	} {
+		c, err := AsOf(db, tt.date)
+		if err != nil || c.ID() != tt.cycle {
+			t.Fatalf("AsOf(%v) = %v, %v; want %s", tt.date, c, err, tt.cycle)
+		}
+		var name string
+		if err := db.QueryRow(`SELECT ARPT_NAME FROM "APT_BASE_ASOF_` + tt.cycle + `" WHERE ARPT_ID = '0J0'`).Scan(&name); err != nil || name != tt.name {
+			t.Errorf("0J0 as of %s = %q, %v; want %q", tt.cycle, name, err, tt.name)
+		}
+	}
+
+	var asOf, orig int
+	db.QueryRow(`SELECT count(*) FROM APT_RWY_ASOF_2602`).Scan(&asOf)
+	openTestDB(t).QueryRow(`SELECT count(*) FROM APT_RWY`).Scan(&orig)
+	if asOf != orig {
+		t.Errorf("APT_RWY as of 2602 has %d rows, want %d", asOf, orig)
+	}
+
+	for _, d := range []time.Time{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)} {
+		if _, err := AsOf(db, d); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AsOf(%v) error = %v, want ErrNotFound", d, err)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Hold is a published holding pattern from HPF_BASE with its speed-band
+// altitudes, charts and remarks.
+type Hold struct {
+	Name    string // HP_NAME, e.g. "AABEE INT*GA*K7" or "ALAMOSA VORTAC*CO"
+	No      int    // HP_NO, distinguishes holds at the same fix
+	State   string // STATE_CODE
+	Country string // COUNTRY_CODE
+
+	// FixID is the holding fix; it is empty when the hold is at the navaid.
+	FixID   string
+	Region  string // ICAO_REGION_CODE of the fix
+	NavID   string // the navaid the hold is defined from, if any
+	NavType string // NAV_TYPE, e.g. "VORTAC", "LD" or "LO"
+
+	Direction     string          // HOLD_DIRECTION, the side of the fix, e.g. "NE"
+	Course        sql.NullFloat64 // HOLD_DEG_OR_CRS, the radial, course or bearing held on
+	Azimuth       string          // AZIMUTH, what Course is: "RAD", "CRS", "BRG" or "RNAV"
+	InboundCourse float64         // COURSE_INBOUND_DEG, degrees magnetic
+	RightTurns    bool            // TURN_DIRECTION is "R"; standard holds turn right
+	LegLengthNM   sql.NullFloat64 // LEG_LENGTH_DIST; holds without it are timed
+
+	// Lat and Lon are the holding fix or navaid position when Resolved.
+	Lat, Lon float64
+	Resolved bool
+	// MagVar is the variation used to convert the inbound course to true,
+	// from the hold's navaid or else the nearest navaid, east positive.
+	MagVar sql.NullFloat64
+
+	Altitudes []HoldAltitude
+	Charts    []string // HPF_CHRT.CHARTING_TYPE_DESC, e.g. "IAP"
+	Remarks   []Remark
+}
+
+// HoldAltitude is an HPF_SPD_ALT row: the altitudes at which the hold may be
+// flown at a maximum holding speed.
+type HoldAltitude struct {
+	Speed    int             // SPEED_RANGE, maximum holding airspeed in knots
+	Min, Max sql.NullFloat64 // feet MSL
+	Raw      string          // ALTITUDE as published, e.g. "30/54"
+}
+
+// parseHoldAltitude parses an HPF_SPD_ALT ALTITUDE value. The value is a
+// minimum and maximum in hundreds of feet separated by a slash, e.g. "30/54"
+// for 3,000 to 5,400 feet; a single number is a minimum only.
+func parseHoldAltitude(s string) (min, max sql.NullFloat64) {
+	lo, hi, _ := strings.Cut(s, "/")
+	parse := func(v string) sql.NullFloat64 {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return sql.NullFloat64{}
+		}
+		return sql.NullFloat64{Float64: f * 100, Valid: true}
+	}
+	return parse(lo), parse(hi)
+}
+
+// TrueInboundCourse returns the inbound course in degrees true. It is the
+// magnetic course when no variation is known.
+func (h *Hold) TrueInboundCourse() float64 {
+	return normalizeBearing(h.InboundCourse + h.MagVar.Float64)
+}
+
+// altitudeFor returns the speed band that applies to an airspeed: the
+// slowest band whose maximum speed is at least speed.
+func (h *Hold) altitudeFor(speed float64) *HoldAltitude {
+	var best *HoldAltitude
+	for i := range h.Altitudes {
+		a := &h.Altitudes[i]
+		if float64(a.Speed) >= speed && (best == nil || a.Speed < best.Speed) {
+			best = a
+		}
+	}
+	return best
+}
+
+// holdTurnRadiusNM returns the turn radius in NM at a true airspeed in knots
+// for a standard-rate turn, limited to 25 degrees of bank.
+func holdTurnRadiusNM(speed float64) float64 {
+	standardRate := speed / (20 * math.Pi)
+	bankLimited := speed * speed / (11.26 * math.Tan(deg2rad(25))) / 6076.12
+	return math.Max(standardRate, bankLimited)
+}
+
+// racetrackArcSteps is the number of segments drawn for each 180 degree turn.
+const racetrackArcSteps = 18
+
+// Racetrack returns the hold as a closed polyline for display, starting and
+// ending at the holding fix. The pattern is drawn without wind at the given
+// airspeed in knots; zero uses the slowest published speed band, or 200
+// knots when there is none. Legs are LegLengthNM long, or else timed at one
+// minute, or one and a half minutes when the hold's minimum altitude for
+// the speed is above 14,000 feet. The hold must be Resolved.
+func (h *Hold) Racetrack(speed float64) ([]LatLon, error) {
+	if !h.Resolved {
+		return nil, fmt.Errorf("hold %s: position not resolved: %w", h.Name, ErrNotFound)
+	}
+	if speed <= 0 {
+		speed = 200
+		if a := h.altitudeFor(0); a != nil {
+			speed = float64(a.Speed)
+		}
+	}
+	leg := h.LegLengthNM.Float64
+	if !h.LegLengthNM.Valid {
+		minutes := 1.0
+		if a := h.altitudeFor(speed); a != nil && a.Min.Float64 > 14000 {
+			minutes = 1.5
+		}
+		leg = speed * minutes / 60
+	}
+	r := holdTurnRadiusNM(speed)
+	inbound := h.TrueInboundCourse()
+	side := -1.0
+	if h.RightTurns {
+		side = 1
+	}
+
+	out := []LatLon{{h.Lat, h.Lon}}
+	// arc appends a 180 degree turn about a centre, starting at the bearing
+	// from the centre to the current position.
+	arc := func(cLat, cLon, start float64) {
+		for i := 1; i <= racetrackArcSteps; i++ {
+			lat, lon := destination(cLat, cLon, normalizeBearing(start+side*180*float64(i)/racetrackArcSteps), r)
+			out = append(out, LatLon{lat, lon})
+		}
+	}
+	// Turn outbound about a centre abeam the fix on the turn side, fly the
+	// outbound leg, turn inbound about a centre one leg length back, and
+	// fly the inbound leg to the fix.
+	c1Lat, c1Lon := destination(h.Lat, h.Lon, normalizeBearing(inbound+side*90), r)
+	arc(c1Lat, c1Lon, normalizeBearing(inbound+180+side*90))
+	c2Lat, c2Lon := destination(c1Lat, c1Lon, normalizeBearing(inbound+180), leg)
+	arc(c2Lat, c2Lon, normalizeBearing(inbound+side*90))
+	out = append(out, LatLon{h.Lat, h.Lon})
+	return out, nil
+}
+
+// LookupHolds returns the holding patterns at a fix or navaid ident, or the
+// hold with the given HP_NAME, ordered by name and HP_NO. Each hold is
+// resolved to its fix or navaid position. It returns ErrNotFound when
+// nothing matches.
+func LookupHolds(db *sql.DB, ident string) ([]Hold, error) {
+	recs, err := queryRecords(db, `SELECT * FROM "HPF_BASE"
+WHERE "FIX_ID" = ?1 OR ("NAV_ID" = ?1 AND ("FIX_ID" IS NULL OR "FIX_ID" = '')) OR "HP_NAME" = ?1
+ORDER BY "HP_NAME", "HP_NO"`, ident)
+	if err != nil {
+		return nil, fmt.Errorf("lookup hold %s: %w", ident, err)
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("hold %s: %w", ident, ErrNotFound)
+	}
+	holds := make([]Hold, len(recs))
+	for i, r := range recs {
+		h := &holds[i]
+		*h = holdOf(r)
+		if err := resolveHold(db, h); err != nil {
+			return nil, err
+		}
+		if err := loadHoldDetails(db, h); err != nil {
+			return nil, err
+		}
+	}
+	return holds, nil
+}
+
+func holdOf(r record) Hold {
+	return Hold{
+		Name:          r.str("HP_NAME"),
+		No:            int(r.float("HP_NO").Float64),
+		State:         r.str("STATE_CODE"),
+		Country:       r.str("COUNTRY_CODE"),
+		FixID:         r.str("FIX_ID"),
+		Region:        r.str("ICAO_REGION_CODE"),
+		NavID:         r.str("NAV_ID"),
+		NavType:       r.str("NAV_TYPE"),
+		Direction:     r.str("HOLD_DIRECTION"),
+		Course:        r.num("HOLD_DEG_OR_CRS"),
+		Azimuth:       r.str("AZIMUTH"),
+		InboundCourse: r.float("COURSE_INBOUND_DEG").Float64,
+		RightTurns:    r.str("TURN_DIRECTION") == "R",
+		LegLengthNM:   r.float("LEG_LENGTH_DIST"),
+	}
+}
+
+// resolveHold locates the holding fix, or the navaid for holds at a navaid,
+// and picks up the magnetic variation from the navaid. Holds whose points
+// are not in the database are left unresolved.
+func resolveHold(db *sql.DB, h *Hold) error {
+	var near *LatLon
+	if h.FixID != "" {
+		fixes, err := ResolveFix(db, h.FixID, nil)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		for _, f := range fixes {
+			if f.Region == h.Region || h.Region == "" {
+				h.Lat, h.Lon, h.Resolved = f.Lat, f.Lon, true
+				break
+			}
+		}
+		if h.Resolved {
+			near = &LatLon{h.Lat, h.Lon}
+		}
+	}
+
+	if h.NavID != "" {
+		navs, err := LookupNavaids(db, h.NavID)
+		if err != nil {
+			return err
+		}
+		var nav *Navaid
+		for i := range navs {
+			n := &navs[i]
+			switch {
+			case nav == nil,
+				near != nil && DistanceNM(near.Lat, near.Lon, n.Lat, n.Lon) < DistanceNM(near.Lat, near.Lon, nav.Lat, nav.Lon),
+				near == nil && n.State == h.State && nav.State != h.State:
+				nav = n
+			}
+		}
+		if nav != nil {
+			h.MagVar = nav.MagVar
+			if h.FixID == "" {
+				h.Lat, h.Lon, h.Resolved = nav.Lat, nav.Lon, true
+			}
+		}
+	}
+
+	if h.Resolved && !h.MagVar.Valid {
+		v, err := nearestMagVar(db, h.Lat, h.Lon)
+		if err != nil {
+			return fmt.Errorf("hold %s: %w", h.Name, err)
+		}
+		h.MagVar = v
+	}
+	return nil
+}
+
+// nearestMagVar returns the magnetic variation of the navaid nearest to a
+// point within a few degrees, east positive.
+func nearestMagVar(db *sql.DB, lat, lon float64) (sql.NullFloat64, error) {
+	recs, err := queryRecords(db, `SELECT "MAG_VARN", "MAG_VARN_HEMIS" FROM "NAV_BASE"
+WHERE typeof("MAG_VARN") = 'real' AND "LAT_DECIMAL" BETWEEN ?1 - 5 AND ?1 + 5 AND "LONG_DECIMAL" BETWEEN ?2 - 5 AND ?2 + 5
+ORDER BY ("LAT_DECIMAL" - ?1) * ("LAT_DECIMAL" - ?1) + ("LONG_DECIMAL" - ?2) * ("LONG_DECIMAL" - ?2) * ?3
+LIMIT 1`, lat, lon, math.Pow(math.Cos(deg2rad(lat)), 2))
+	if err != nil || len(recs) == 0 {
+		return sql.NullFloat64{}, err
+	}
+	return signedMagVar(recs[0].float("MAG_VARN"), recs[0].str("MAG_VARN_HEMIS")), nil
+}
+
+func loadHoldDetails(db *sql.DB, h *Hold) error {
+	const where = `WHERE "HP_NAME" = ? AND "HP_NO" = ?`
+	alts, err := queryRecords(db, `SELECT * FROM "HPF_SPD_ALT" `+where+` ORDER BY "SPEED_RANGE"`, h.Name, h.No)
+	if err != nil {
+		return fmt.Errorf("hold %s altitudes: %w", h.Name, err)
+	}
+	for _, r := range alts {
+		a := HoldAltitude{Speed: int(r.num("SPEED_RANGE").Float64), Raw: r.str("ALTITUDE")}
+		a.Min, a.Max = parseHoldAltitude(a.Raw)
+		h.Altitudes = append(h.Altitudes, a)
+	}
+
+	charts, err := queryRecords(db, `SELECT "CHARTING_TYPE_DESC" FROM "HPF_CHRT" `+where+` ORDER BY rowid`, h.Name, h.No)
+	if err != nil {
+		return fmt.Errorf("hold %s charts: %w", h.Name, err)
+	}
+	for _, r := range charts {
+		h.Charts = append(h.Charts, r.str("CHARTING_TYPE_DESC"))
+	}
+
+	rmks, err := queryRecords(db, `SELECT * FROM "HPF_RMK" `+where+` ORDER BY "REF_COL_SEQ_NO"`, h.Name, h.No)
+	if err != nil {
+		return fmt.Errorf("hold %s remarks: %w", h.Name, err)
+	}
+	for _, r := range rmks {
+		h.Remarks = append(h.Remarks, remarkOf(r))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseHoldAltitude(t *testing.T) {
+	tests := []struct {
+		in       string
+		min, max float64
+		minValid bool
+		maxValid bool
+	}{
+		{"30/54", 3000, 5400, true, true},
+		{"110/175", 11000, 17500, true, true},
+		{"20/20", 2000, 2000, true, true},
+		{"50", 5000, 0, true, false},
+		{"", 0, 0, false, false},
+	}
+	for _, tt := range tests {
+		min, max := parseHoldAltitude(tt.in)
+		if min.Valid != tt.minValid || max.Valid != tt.maxValid || min.Float64 != tt.min || max.Float64 != tt.max {
+			t.Errorf("parseHoldAltitude(%q) = %v, %v; want %v, %v", tt.in, min, max, tt.min, tt.max)
+		}
+	}
+}
+
+func TestLookupHolds(t *testing.T) {
+	db := openTestDB(t)
+
+	holds, err := LookupHolds(db, "JOTAV")
+	if err != nil {
+		t.Fatalf("LookupHolds(JOTAV): %v", err)
+	}
+	h := holds[0]
+	if len(holds) != 1 || h.No != 3 || h.NavID != "GAD" || h.Azimuth != "RAD" || !h.RightTurns || !h.Resolved {
+		t.Fatalf("JOTAV = %+v", holds)
+	}
+	if len(h.Altitudes) != 1 || h.Altitudes[0].Speed != 200 || h.Altitudes[0].Min.Float64 != 5000 || h.Altitudes[0].Max.Float64 != 6000 {
+		t.Errorf("JOTAV altitudes = %+v, want 200 kt 5000-6000", h.Altitudes)
+	}
+
+	// A hold at a navaid has no fix and takes the navaid's variation.
+	holds, err = LookupHolds(db, "LWT")
+	if err != nil {
+		t.Fatalf("LookupHolds(LWT): %v", err)
+	}
+	h = holds[0]
+	if h.FixID != "" || !h.Resolved || h.MagVar.Float64 != 15 || h.TrueInboundCourse() != 109 {
+		t.Errorf("LWT = %+v, true inbound %v", h, h.TrueInboundCourse())
+	}
+
+	if _, err := LookupHolds(db, "NOHOLD"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupHolds(NOHOLD) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHold_Racetrack(t *testing.T) {
+	for _, right := range []bool{true, false} {
+		h := Hold{
+			Name: "TEST", Lat: 40, Lon: -100, Resolved: true, RightTurns: right,
+			InboundCourse: 80, MagVar: sql.NullFloat64{Float64: 10, Valid: true},
+			Altitudes: []HoldAltitude{{Speed: 200, Min: sql.NullFloat64{Float64: 5000, Valid: true}, Max: sql.NullFloat64{Float64: 9000, Valid: true}}},
+		}
+		pts, err := h.Racetrack(0)
+		if err != nil {
+			t.Fatalf("Racetrack: %v", err)
+		}
+		if n := len(pts); n != 2*racetrackArcSteps+2 || pts[0] != pts[n-1] || pts[0] != (LatLon{40, -100}) {
+			t.Fatalf("racetrack has %d points from %v to %v", n, pts[0], pts[n-1])
+		}
+
+		// The first turn ends two turn radii abeam the fix on the turn
+		// side; the second ends one minute behind the fix.
+		side := 90.0
+		if !right {
+			side = -90
+		}
+		abeam := pts[racetrackArcSteps]
+		if d := DistanceNM(40, -100, abeam.Lat, abeam.Lon); math.Abs(d-2*holdTurnRadiusNM(200)) > 0.01 {
+			t.Errorf("right=%v: abeam point %.3f NM from fix, want %.3f", right, d, 2*holdTurnRadiusNM(200))
+		}
+		if b := initialBearing(40, -100, abeam.Lat, abeam.Lon); angleDiff(b, 90+side) > 0.5 {
+			t.Errorf("right=%v: abeam point bearing %.1f, want %.1f", right, b, 90+side)
+		}
+		behind := pts[2*racetrackArcSteps]
+		if d := DistanceNM(40, -100, behind.Lat, behind.Lon); math.Abs(d-200.0/60) > 0.01 {
+			t.Errorf("right=%v: inbound leg %.3f NM, want %.3f", right, d, 200.0/60)
+		}
+		if b := initialBearing(40, -100, behind.Lat, behind.Lon); angleDiff(b, 270) > 0.5 {
+			t.Errorf("right=%v: inbound leg starts on bearing %.1f from fix, want 270", right, b)
+		}
+	}
+
+	if _, err := (&Hold{Name: "X"}).Racetrack(200); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unresolved Racetrack error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,367 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// coord is a longitude/latitude pair in decimal degrees, in the x/y order
+// used by GIS formats.
+type coord struct{ lon, lat float64 }
+
+type geomKind int
+
+const (
+	geomPoint geomKind = iota
+	geomLineString
+	geomPolygon
+)
+
+// geometry is a point, line string or polygon. A point has one ring of one
+// coord, a line string has one ring, and a polygon has a closed outer ring
+// followed by any inner rings.
+type geometry struct {
+	kind  geomKind
+	rings [][]coord
+}
+
+func pointGeom(lat, lon float64) geometry {
+	return geometry{geomPoint, [][]coord{{{lon, lat}}}}
+}
+
+func lineGeom(cs []coord) geometry {
+	return geometry{geomLineString, [][]coord{cs}}
+}
+
+// polygonGeom builds a polygon from one or more rings, closing any ring whose
+// last coord does not repeat its first.
+func polygonGeom(rings ...[]coord) geometry {
+	g := geometry{kind: geomPolygon}
+	for _, r := range rings {
+		if len(r) > 0 && r[0] != r[len(r)-1] {
+			r = append(r, r[0])
+		}
+		g.rings = append(g.rings, r)
+	}
+	return g
+}
+
+// circleGeom approximates a circle of radiusNM around a point with a
+// 72-sided polygon.
+func circleGeom(lat, lon, radiusNM float64) geometry {
+	ring := make([]coord, 0, 73)
+	for brg := 0.0; brg < 360; brg += 5 {
+		la, lo := destination(lat, lon, brg, radiusNM)
+		ring = append(ring, coord{lo, la})
+	}
+	return polygonGeom(ring)
+}
+
+// envelope returns the bounding box of the geometry as minX, maxX, minY, maxY.
+func (g geometry) envelope() (minX, maxX, minY, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, r := range g.rings {
+		for _, c := range r {
+			minX, maxX = math.Min(minX, c.lon), math.Max(maxX, c.lon)
+			minY, maxY = math.Min(minY, c.lat), math.Max(maxY, c.lat)
+		}
+	}
+	return minX, maxX, minY, maxY
+}
+
+type layerColumn struct {
+	name     string
+	dataType string
+}
+
+type feature struct {
+	geom   geometry
+	values []interface{} // one per layer column
+}
+
+// layer is a set of features sharing a geometry kind and attribute columns.
+// Attribute columns are the original NASR columns of the source table.
+type layer struct {
+	name     string
+	kind     geomKind
+	columns  []layerColumn
+	features []feature
+}
+
+// layerDef names a layer and the function that builds it from a database.
+type layerDef struct {
+	name  string
+	kind  geomKind
+	build func(db *sql.DB, l *layer) error
+}
+
+func geoPackageLayers() []layerDef {
+	return []layerDef{
+		{"airports", geomPoint, pointLayer("APT_BASE")},
+		{"navaids", geomPoint, pointLayer("NAV_BASE")},
+		{"fixes", geomPoint, pointLayer("FIX_BASE")},
+		{"awos_stations", geomPoint, pointLayer("AWOS")},
+		{"comm_outlets", geomPoint, pointLayer("COM")},
+		{"runways", geomLineString, buildRunwayLayer},
+		{"airway_segments", geomLineString, buildAirwaySegmentLayer},
+		{"mtr_routes", geomLineString, buildMTRLayer},
+		{"artcc_boundaries", geomPolygon, buildARTCCLayer},
+		{"maa_areas", geomPolygon, buildMAALayer},
+	}
+}
+
+// buildLayers runs each layer definition against the database.
+func buildLayers(db *sql.DB, defs []layerDef) ([]*layer, error) {
+	layers := make([]*layer, 0, len(defs))
+	for _, def := range defs {
+		l := &layer{name: def.name, kind: def.kind}
+		if err := def.build(db, l); err != nil {
+			return nil, fmt.Errorf("layer %s: %w", def.name, err)
+		}
+		layers = append(layers, l)
+	}
+	return layers, nil
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryRows runs a query and returns its column definitions and all rows.
+func queryRows(db queryer, query string, args ...interface{}) ([]layerColumn, [][]interface{}, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	cols := make([]layerColumn, len(types))
+	for i, ct := range types {
+		cols[i] = layerColumn{ct.Name(), ct.DatabaseTypeName()}
+	}
+
+	var out [][]interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, err
+		}
+		out = append(out, vals)
+	}
+	return cols, out, rows.Err()
+}
+
+func columnIndex(cols []layerColumn, name string) int {
+	for i, c := range cols {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func asString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
+
+// pointLayer builds a point layer from every row of a table that has
+// LAT_DECIMAL and LONG_DECIMAL values.
+func pointLayer(table string) func(*sql.DB, *layer) error {
+	return func(db *sql.DB, l *layer) error {
+		cols, rows, err := queryRows(db, fmt.Sprintf(`SELECT * FROM %q`, table))
+		if err != nil {
+			return err
+		}
+		latIdx, lonIdx := columnIndex(cols, "LAT_DECIMAL"), columnIndex(cols, "LONG_DECIMAL")
+		if latIdx < 0 || lonIdx < 0 {
+			return fmt.Errorf("%s has no LAT_DECIMAL/LONG_DECIMAL columns", table)
+		}
+		l.columns = cols
+		for _, r := range rows {
+			lat, ok1 := asFloat(r[latIdx])
+			lon, ok2 := asFloat(r[lonIdx])
+			if !ok1 || !ok2 {
+				continue
+			}
+			l.features = append(l.features, feature{pointGeom(lat, lon), r})
+		}
+		return nil
+	}
+}
+
+// buildRunwayLayer joins each APT_RWY row to its two APT_RWY_END rows and
+// draws a line between the runway end positions.
+func buildRunwayLayer(db *sql.DB, l *layer) error {
+	cols, rows, err := queryRows(db, `SELECT r.*,
+  e1."LAT_DECIMAL", e1."LONG_DECIMAL", e2."LAT_DECIMAL", e2."LONG_DECIMAL"
+FROM "APT_RWY" r
+JOIN "APT_RWY_END" e1 ON e1."SITE_NO" = r."SITE_NO" AND e1."RWY_ID" = r."RWY_ID"
+JOIN "APT_RWY_END" e2 ON e2."SITE_NO" = r."SITE_NO" AND e2."RWY_ID" = r."RWY_ID"
+  AND e1."RWY_END_ID" < e2."RWY_END_ID"`)
+	if err != nil {
+		return err
+	}
+	n := len(cols) - 4
+	l.columns = cols[:n]
+	for _, r := range rows {
+		var c [4]float64
+		ok := true
+		for i := range c {
+			if c[i], ok = asFloat(r[n+i]); !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		l.features = append(l.features, feature{lineGeom([]coord{{c[1], c[0]}, {c[3], c[2]}}), r[:n]})
+	}
+	return nil
+}
+
+// buildAirwaySegmentLayer draws each AWY_SEG_ALT row as a line from its
+// FROM_POINT to its TO_POINT, resolving both against FIX_BASE and NAV_BASE.
+func buildAirwaySegmentLayer(db *sql.DB, l *layer) error {
+	idx, err := loadPointIndex(db)
+	if err != nil {
+		return err
+	}
+	cols, rows, err := queryRows(db, `SELECT * FROM "AWY_SEG_ALT" ORDER BY "AWY_LOCATION", "AWY_ID", "POINT_SEQ"`)
+	if err != nil {
+		return err
+	}
+	l.columns = cols
+	fromIdx, typeIdx := columnIndex(cols, "FROM_POINT"), columnIndex(cols, "FROM_PT_TYPE")
+	regionIdx, toIdx := columnIndex(cols, "ICAO_REGION_CODE"), columnIndex(cols, "TO_POINT")
+	for _, r := range rows {
+		from, ok := idx.resolve(asString(r[fromIdx]), asString(r[regionIdx]), pointKindOf(asString(r[typeIdx])), nil)
+		if !ok {
+			continue
+		}
+		to, ok := idx.resolve(asString(r[toIdx]), "", pointAny, &from)
+		if !ok {
+			continue
+		}
+		l.features = append(l.features, feature{lineGeom([]coord{{from.lon, from.lat}, {to.lon, to.lat}}), r})
+	}
+	return nil
+}
+
+// buildMTRLayer draws each MTR_BASE route through its MTR_PT points in
+// ROUTE_PT_SEQ order.
+func buildMTRLayer(db *sql.DB, l *layer) error {
+	cols, rows, err := queryRows(db, `SELECT * FROM "MTR_BASE"`)
+	if err != nil {
+		return err
+	}
+	l.columns = cols
+	typeIdx, idIdx := columnIndex(cols, "ROUTE_TYPE_CODE"), columnIndex(cols, "ROUTE_ID")
+	for _, r := range rows {
+		_, pts, err := queryRows(db, `SELECT "LAT_DECIMAL", "LONG_DECIMAL" FROM "MTR_PT"
+WHERE "ROUTE_TYPE_CODE" = ? AND "ROUTE_ID" = ? ORDER BY "ROUTE_PT_SEQ"`, r[typeIdx], r[idIdx])
+		if err != nil {
+			return err
+		}
+		line := coordsOf(pts)
+		if len(line) < 2 {
+			continue
+		}
+		l.features = append(l.features, feature{lineGeom(line), r})
+	}
+	return nil
+}
+
+// buildARTCCLayer draws one feature per polygon assembled in ARB_POLYGON,
+// with any holes as inner rings.
+func buildARTCCLayer(db *sql.DB, l *layer) error {
+	polys, err := loadARBPolygons(db)
+	if err != nil {
+		return err
+	}
+	l.columns = []layerColumn{
+		{"LOCATION_ID", "TEXT"},
+		{"LOCATION_NAME", "TEXT"},
+		{"ALTITUDE", "TEXT"},
+		{"TYPE", "TEXT"},
+	}
+	for _, p := range polys {
+		b := p.boundary
+		l.features = append(l.features, feature{polygonGeom(p.rings...),
+			[]interface{}{b.LocationID, b.LocationName, b.Altitude, b.Type}})
+	}
+	return nil
+}
+
+// buildMAALayer draws each MAA_BASE area from its MAA_SHP points, or as a
+// circle of MAA_RADIUS around its center when it has no shape.
+func buildMAALayer(db *sql.DB, l *layer) error {
+	cols, rows, err := queryRows(db, `SELECT * FROM "MAA_BASE"`)
+	if err != nil {
+		return err
+	}
+	l.columns = cols
+	idIdx, radiusIdx := columnIndex(cols, "MAA_ID"), columnIndex(cols, "MAA_RADIUS")
+	latIdx, lonIdx := columnIndex(cols, "LATITUDE"), columnIndex(cols, "LONGITUDE")
+	for _, r := range rows {
+		_, pts, err := queryRows(db, `SELECT "LATITUDE", "LONGITUDE" FROM "MAA_SHP"
+WHERE "MAA_ID" = ? ORDER BY "POINT_SEQ"`, r[idIdx])
+		if err != nil {
+			return err
+		}
+		var ring []coord
+		for _, p := range pts {
+			lat, ok1 := parseDMS(asString(p[0]))
+			lon, ok2 := parseDMS(asString(p[1]))
+			if ok1 && ok2 {
+				ring = append(ring, coord{lon, lat})
+			}
+		}
+		if len(ring) >= 3 {
+			l.features = append(l.features, feature{polygonGeom(ring), r})
+			continue
+		}
+		lat, ok1 := parseDMS(asString(r[latIdx]))
+		lon, ok2 := parseDMS(asString(r[lonIdx]))
+		radius, ok3 := asFloat(r[radiusIdx])
+		if ok1 && ok2 && ok3 && radius > 0 {
+			l.features = append(l.features, feature{circleGeom(lat, lon, radius), r})
+		}
+	}
+	return nil
+}
+
+// coordsOf converts rows of (lat, lon) values to coords, skipping rows
+// without numeric positions.
+func coordsOf(rows [][]interface{}) []coord {
+	var cs []coord
+	for _, p := range rows {
+		lat, ok1 := asFloat(p[0])
+		lon, ok2 := asFloat(p[1])
+		if ok1 && ok2 {
+			cs = append(cs, coord{lon, lat})
+		}
+	}
+	return cs
+}
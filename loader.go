@@ -5,15 +5,26 @@ import (
 	"bufio"
 	"database/sql"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"strconv"
 	"strings"
 
 	_ "modernc.org/sqlite"
 )
 
-func loadAllCSVs(db *sql.DB, zr *zip.Reader, tables map[string]*tableSchema) error {
+// rejectTable receives the records RejectRowErrors leaves out.
+const rejectTable = "NASR_REJECT"
+
+func loadAllCSVs(db *sql.DB, zr *zip.Reader, tables map[string]*tableSchema, cfg *config) error {
+	if cfg.rowErrors == RejectRowErrors {
+		if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS "` + rejectTable + `" (
+			"TABLE_NAME" TEXT, "FILE" TEXT, "LINE" INTEGER, "ERROR" TEXT, "RAW" TEXT)`); err != nil {
+			return fmt.Errorf("create %s: %w", rejectTable, err)
+		}
+	}
 	for _, f := range zr.File {
 		if !strings.HasSuffix(f.Name, ".csv") {
 			continue
@@ -31,6 +42,7 @@ func loadAllCSVs(db *sql.DB, zr *zip.Reader, tables map[string]*tableSchema) err
 
 		schema, ok := tables[tableName]
 		if !ok {
+			log.Printf("WARNING: %s has no _CSV_DATA_STRUCTURE.csv definition; skipping", f.Name)
 			continue
 		}
 
@@ -38,7 +50,7 @@ func loadAllCSVs(db *sql.DB, zr *zip.Reader, tables map[string]*tableSchema) err
 		if err != nil {
 			return fmt.Errorf("open %s: %w", f.Name, err)
 		}
-		err = loadCSV(db, rc, schema)
+		err = loadCSV(db, rc, f.Name, schema, cfg)
 		rc.Close()
 		if err != nil {
 			return fmt.Errorf("load %s: %w", tableName, err)
@@ -47,7 +59,9 @@ func loadAllCSVs(db *sql.DB, zr *zip.Reader, tables map[string]*tableSchema) err
 	return nil
 }
 
-func loadCSV(db *sql.DB, r io.Reader, schema *tableSchema) error {
+// loadCSV loads one data file into its table. Values are mapped to columns
+// by the header's column names, so the file's column order does not matter.
+func loadCSV(db *sql.DB, r io.Reader, file string, schema *tableSchema, cfg *config) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return err
@@ -72,52 +86,171 @@ func loadCSV(db *sql.DB, r io.Reader, schema *tableSchema) error {
 		br.Discard(3)
 	}
 
-	cr := csv.NewReader(br)
+	rec := &rawRecorder{r: br}
+	cr := csv.NewReader(rec)
 
-	// Read and discard header row.
-	if _, err := cr.Read(); err != nil {
-		return err
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	fields, mismatch := mapColumns(header, schema)
+	if mismatch != nil {
+		mismatch.File = file
+		if cfg.report != nil {
+			cfg.report.Columns = append(cfg.report.Columns, *mismatch)
+		}
+		if cfg.strictColumns {
+			return fmt.Errorf("%s: header does not match the structure file: unknown columns %s, missing columns %s",
+				file, columnList(mismatch.Unknown), columnList(mismatch.Missing))
+		}
+		log.Printf("WARNING: %s: header does not match the structure file: unknown columns %s dropped, missing columns %s loaded as NULL",
+			file, columnList(mismatch.Unknown), columnList(mismatch.Missing))
 	}
 
+	conv := newConverter(schema, cfg)
+	var reject *sql.Stmt
+	start := cr.InputOffset()
 	for {
 		row, err := cr.Read()
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
-			return err
+		var line int
+		var pe *csv.ParseError
+		switch {
+		case errors.As(err, &pe):
+			line = pe.StartLine
+			err = pe.Err
+			if pe.Err != csv.ErrFieldCount {
+				err = fmt.Errorf("column %d: %w", pe.Column, pe.Err)
+			}
+		case err != nil:
+			return fmt.Errorf("%s: %w", file, err)
+		default:
+			line, _ = cr.FieldPos(0)
+			vals := make([]interface{}, len(schema.columns))
+			for i := range schema.columns {
+				if j := fields[i]; j >= 0 && j < len(row) {
+					vals[i] = conv.convert(i, row[j])
+				}
+			}
+			_, err = stmt.Exec(vals...)
 		}
 
-		vals := make([]interface{}, len(schema.columns))
-		for i, col := range schema.columns {
-			if i < len(row) {
-				vals[i] = convertValue(row[i], col, schema.name)
+		end := cr.InputOffset()
+		if err != nil {
+			if cfg.rowErrors == FailOnRowError {
+				return fmt.Errorf("%s line %d: %w", file, line, err)
+			}
+			re := RowError{
+				Table: schema.name,
+				File:  file,
+				Line:  line,
+				Err:   err.Error(),
+				Raw:   strings.TrimRight(rec.text(start, end), "\r\n"),
+			}
+			if cfg.report != nil {
+				cfg.report.Rows = append(cfg.report.Rows, re)
+			}
+			if cfg.rowErrors == RejectRowErrors {
+				if reject == nil {
+					if reject, err = tx.Prepare(`INSERT INTO "` + rejectTable + `" VALUES (?, ?, ?, ?, ?)`); err != nil {
+						return err
+					}
+					defer reject.Close()
+				}
+				if _, err := reject.Exec(re.Table, re.File, re.Line, re.Err, re.Raw); err != nil {
+					return fmt.Errorf("%s line %d: reject: %w", file, line, err)
+				}
 			} else {
-				vals[i] = nil
+				log.Printf("WARNING: %s line %d: skipping record: %s", file, line, re.Err)
 			}
 		}
+		rec.discard(end)
+		start = end
+	}
+	conv.finish(cfg.report)
+
+	return tx.Commit()
+}
 
-		if _, err := stmt.Exec(vals...); err != nil {
-			return err
+// mapColumns returns, for each schema column, the index of the header field
+// with its name, or -1. Names are normalized as in the structure files and
+// compared without regard to case (CDR.csv has "RCode" for RCODE). The
+// mismatch is nil when the header has exactly the schema's columns.
+func mapColumns(header []string, schema *tableSchema) ([]int, *ColumnMismatch) {
+	byName := make(map[string]int, len(header))
+	for i, h := range header {
+		name := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(h), " ", "_"))
+		if _, dup := byName[name]; !dup {
+			byName[name] = i
 		}
 	}
 
-	return tx.Commit()
+	fields := make([]int, len(schema.columns))
+	used := make(map[int]bool, len(header))
+	var missing []string
+	for i, col := range schema.columns {
+		j, ok := byName[strings.ToUpper(col.name)]
+		if !ok {
+			fields[i] = -1
+			missing = append(missing, col.name)
+			continue
+		}
+		fields[i] = j
+		used[j] = true
+	}
+	var unknown []string
+	for i, h := range header {
+		if !used[i] {
+			unknown = append(unknown, strings.TrimSpace(h))
+		}
+	}
+	if missing == nil && unknown == nil {
+		return fields, nil
+	}
+	return fields, &ColumnMismatch{Table: schema.name, Unknown: unknown, Missing: missing}
 }
 
-// sentinelNulls maps (table, column) pairs to sentinel values that should be
-// treated as NULL. These are placeholder values in the FAA source data that
-// prevent unique constraints from working correctly.
-var sentinelNulls = map[[2]string]string{
-	{"DP_BASE", "DP_COMPUTER_CODE"}: "NOT ASSIGNED",
-	{"DP_APT", "DP_COMPUTER_CODE"}:  "NOT ASSIGNED",
-	{"DP_RTE", "DP_COMPUTER_CODE"}:  "NOT ASSIGNED",
+// rawRecorder keeps what the CSV reader has read from a data file since the
+// last discard, so the raw text of a record can be recovered by its input
+// offsets.
+type rawRecorder struct {
+	r     io.Reader
+	buf   []byte
+	start int64 // input offset of buf[0]
 }
 
-func convertValue(val string, col columnDef, tableName string) interface{} {
-	if sentinel, ok := sentinelNulls[[2]string{tableName, col.name}]; ok && val == sentinel {
-		return nil
+func (rr *rawRecorder) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[:n]...)
+	return n, err
+}
+
+// text returns the input between two offsets at or after the last discard.
+func (rr *rawRecorder) text(from, to int64) string {
+	return string(rr.buf[from-rr.start : to-rr.start])
+}
+
+// discard forgets the input before offset to. The buffer is compacted only
+// once it is mostly consumed, so each record is not copied again.
+func (rr *rawRecorder) discard(to int64) {
+	n := int(to - rr.start)
+	if n < 1<<16 || n < len(rr.buf)/2 {
+		return
 	}
+	rr.buf = append(rr.buf[:0], rr.buf[n:]...)
+	rr.start = to
+}
+
+func columnList(cols []string) string {
+	if len(cols) == 0 {
+		return "none"
+	}
+	return strings.Join(cols, ", ")
+}
+
+func convertValue(val string, col columnDef) interface{} {
 	if val == "" && col.nullable {
 		return nil
 	}
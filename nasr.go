@@ -13,8 +13,14 @@ import (
 
 // Extract reads a NASR 28-day subscription zip file and writes its CSV data
 // into a new SQLite database at the given path. The output database must not
-// already exist.
-func Extract(nasrSubscription, sqliteDatabase string) error {
+// already exist. Options enable optional layers built on top of the tables.
+//
+// The AIRAC cycle is taken from the subscription's file names and recorded in
+// the NASR_CYCLE table (see DatabaseCycle). Extract fails if a file name date
+// is not a cycle effective date or the outer and inner names disagree.
+func Extract(nasrSubscription, sqliteDatabase string, opts ...Option) error {
+	cfg := newConfig(opts)
+
 	if _, err := os.Stat(nasrSubscription); err != nil {
 		return fmt.Errorf("input file: %w", err)
 	}
@@ -23,15 +29,21 @@ func Extract(nasrSubscription, sqliteDatabase string) error {
 		return fmt.Errorf("output file already exists: %s", sqliteDatabase)
 	}
 
-	innerZip, data, err := openInnerCSVZip(nasrSubscription)
+	innerZip, data, innerName, err := openInnerCSVZip(nasrSubscription)
 	if err != nil {
 		return fmt.Errorf("open inner CSV zip: %w", err)
 	}
 
+	cyc, haveCycle, err := subscriptionCycle(nasrSubscription, innerName)
+	if err != nil {
+		return fmt.Errorf("AIRAC cycle: %w", err)
+	}
+
 	tables, err := parseSchemas(innerZip)
 	if err != nil {
 		return fmt.Errorf("parse schemas: %w", err)
 	}
+	applyRuleSchema(tables, cfg.rules)
 
 	db, err := sql.Open("sqlite", sqliteDatabase)
 	if err != nil {
@@ -46,6 +58,10 @@ func Extract(nasrSubscription, sqliteDatabase string) error {
 		return fmt.Errorf("set synchronous: %w", err)
 	}
 
+	for _, ts := range derivedTables(tables) {
+		tables[ts.name] = ts
+	}
+
 	fks := foreignKeyDefs()
 	createTables, createIndexes := generateDDL(tables, fks)
 	for _, stmt := range createTables {
@@ -60,10 +76,19 @@ func Extract(nasrSubscription, sqliteDatabase string) error {
 		return fmt.Errorf("reopen inner zip: %w", err)
 	}
 
-	if err := loadAllCSVs(db, innerZip, tables); err != nil {
+	if err := loadAllCSVs(db, innerZip, tables, cfg); err != nil {
 		return fmt.Errorf("load CSVs: %w", err)
 	}
 
+	if haveCycle {
+		if err := checkEffectiveDates(db, tables, cyc); err != nil {
+			return err
+		}
+		if err := recordCycle(db, cyc, innerName); err != nil {
+			return fmt.Errorf("record cycle: %w", err)
+		}
+	}
+
 	// Deduplicate parent tables and create unique indexes. If an index fails
 	// due to duplicate source data, the duplicates are deleted (keeping the
 	// lowest rowid) and the index is retried.
@@ -76,6 +101,14 @@ func Extract(nasrSubscription, sqliteDatabase string) error {
 		return fmt.Errorf("delete orphans: %w", err)
 	}
 
+	// Build derived tables from the cleaned source tables.
+	if err := buildARBPolygons(db); err != nil {
+		return fmt.Errorf("build ARB_POLYGON: %w", err)
+	}
+	if err := buildExplodedTables(db, tables); err != nil {
+		return err
+	}
+
 	// Final FK check — hard failure if any violations remain.
 	rows, err := db.Query("PRAGMA foreign_key_check")
 	if err != nil {
@@ -95,6 +128,28 @@ func Extract(nasrSubscription, sqliteDatabase string) error {
 		return fmt.Errorf("foreign key check failed: %d violations remain", fkViolations)
 	}
 
+	if cfg.fullTextSearch {
+		if err := buildSearchIndex(db, tables); err != nil {
+			return fmt.Errorf("build search index: %w", err)
+		}
+	}
+
+	if cfg.spatialIndex {
+		if err := buildSpatialIndex(db, tables); err != nil {
+			return fmt.Errorf("build spatial index: %w", err)
+		}
+	}
+
+	if cfg.geoPackage {
+		if err := writeGeoPackage(db); err != nil {
+			return fmt.Errorf("write GeoPackage: %w", err)
+		}
+		// A GeoPackage is a single file; leave it without a WAL.
+		if _, err := db.Exec("PRAGMA journal_mode = DELETE"); err != nil {
+			return fmt.Errorf("set journal_mode: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -4,9 +4,12 @@ import (
 	"archive/zip"
 	"bytes"
 	"database/sql"
+	"encoding/csv"
+	"errors"
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -74,8 +77,10 @@ func TestExtract_TableCount(t *testing.T) {
 	if err != nil {
 		t.Fatalf("query: %v", err)
 	}
-	if count != 63 {
-		t.Errorf("expected 63 tables, got %d", count)
+	// 63 FAA tables plus the derived ARB_POLYGON and NASR_CYCLE tables and
+	// the seven exploded list tables.
+	if count != 72 {
+		t.Errorf("expected 72 tables, got %d", count)
 	}
 }
 
@@ -213,9 +218,10 @@ func TestConvertValue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := convertValue(tt.val, tt.col, tt.tableName)
+			schema := &tableSchema{name: tt.tableName, columns: []columnDef{tt.col}}
+			got := newConverter(schema, newConfig(nil)).convert(0, tt.val)
 			if got != tt.want {
-				t.Errorf("convertValue(%q, %+v, %q) = %v (%T), want %v (%T)",
+				t.Errorf("convert(%q, %+v, %q) = %v (%T), want %v (%T)",
 					tt.val, tt.col, tt.tableName, got, got, tt.want, tt.want)
 			}
 		})
@@ -223,7 +229,7 @@ func TestConvertValue(t *testing.T) {
 }
 
 func TestParseSchemas(t *testing.T) {
-	innerZip, _, err := openInnerCSVZip(testZipPath)
+	innerZip, _, _, err := openInnerCSVZip(testZipPath)
 	if err != nil {
 		t.Fatalf("openInnerCSVZip: %v", err)
 	}
@@ -363,10 +369,13 @@ func TestExtract_DPNotAssignedIsNull(t *testing.T) {
 
 // TestOpenInnerCSVZip verifies that the test zip contains the expected inner CSV zip.
 func TestOpenInnerCSVZip(t *testing.T) {
-	zr, data, err := openInnerCSVZip(testZipPath)
+	zr, data, name, err := openInnerCSVZip(testZipPath)
 	if err != nil {
 		t.Fatalf("openInnerCSVZip: %v", err)
 	}
+	if name != "CSV_Data/19_Feb_2026_CSV.zip" {
+		t.Errorf("inner zip name = %q", name)
+	}
 	if len(data) == 0 {
 		t.Fatal("inner zip data is empty")
 	}
@@ -383,3 +392,217 @@ func TestOpenInnerCSVZip(t *testing.T) {
 		t.Errorf("re-created reader has %d files, original has %d", len(zr2.File), len(zr.File))
 	}
 }
+
+func TestLoadCSV_MapsColumnsByName(t *testing.T) {
+	schema := &tableSchema{name: "T", columns: []columnDef{
+		{name: "A", dataType: "TEXT", nullable: true},
+		{name: "B", dataType: "REAL", nullable: true},
+		{name: "C", dataType: "TEXT", nullable: true},
+	}}
+	csvData := "c,X,A\n3,9,1\n"
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	createTables, _ := generateDDL(map[string]*tableSchema{"T": schema}, nil)
+	if _, err := db.Exec(createTables[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	var report Report
+	if err := loadCSV(db, strings.NewReader(csvData), "T.csv", schema, newConfig([]Option{WithReport(&report)})); err != nil {
+		t.Fatalf("loadCSV: %v", err)
+	}
+	var a, c string
+	var b sql.NullFloat64
+	if err := db.QueryRow(`SELECT A, B, C FROM T`).Scan(&a, &b, &c); err != nil {
+		t.Fatal(err)
+	}
+	if a != "1" || b.Valid || c != "3" {
+		t.Errorf("row = %q, %v, %q; want 1, NULL, 3", a, b, c)
+	}
+	if len(report.Columns) != 1 {
+		t.Fatalf("report.Columns = %+v", report.Columns)
+	}
+	if m := report.Columns[0]; m.Table != "T" || m.File != "T.csv" ||
+		strings.Join(m.Unknown, ",") != "X" || strings.Join(m.Missing, ",") != "B" {
+		t.Errorf("mismatch = %+v", m)
+	}
+
+	err = loadCSV(db, strings.NewReader(csvData), "T.csv", schema, newConfig([]Option{WithStrictColumns()}))
+	if err == nil || !strings.Contains(err.Error(), "unknown columns X, missing columns B") {
+		t.Errorf("strict loadCSV error = %v", err)
+	}
+}
+
+func TestExtract_Report(t *testing.T) {
+	var report Report
+	path := filepath.Join(t.TempDir(), "report.db")
+	if err := Extract(testZipPath, path, WithReport(&report), WithStrictColumns()); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(report.Columns) != 0 {
+		t.Errorf("report.Columns = %+v, want none", report.Columns)
+	}
+
+	trimmed := map[string]int{}
+	for _, tc := range report.Trimmed {
+		trimmed[tc.Table+"."+tc.Column] = tc.Count
+	}
+	for _, r := range DefaultRules() {
+		if r.Trim && trimmed[r.Table+"."+r.Column] == 0 {
+			t.Errorf("report.Trimmed has no trimmed values for %s.%s: %+v", r.Table, r.Column, report.Trimmed)
+		}
+	}
+}
+
+func TestLoadAllCSVs_RowErrors(t *testing.T) {
+	schema := &tableSchema{name: "T", columns: []columnDef{
+		{name: "A", dataType: "TEXT"},
+		{name: "B", dataType: "REAL", nullable: true},
+		{name: "C", dataType: "TEXT", nullable: true},
+	}}
+	csvData := "A,B,C\r\n1,2,x\r\n2,3\r\n3,\"4\"5,y\r\n4,5,z\r\n1,6,w\r\n"
+	data := buildZip(t, []zipEntry{{name: "T.csv", data: csvData}})
+
+	load := func(opts ...Option) (*sql.DB, error) {
+		db, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		db.SetMaxOpenConns(1)
+		createTables, _ := generateDDL(map[string]*tableSchema{"T": schema}, nil)
+		if _, err := db.Exec(createTables[0]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`CREATE UNIQUE INDEX idx_T_A ON T (A)`); err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db, loadAllCSVs(db, zr, map[string]*tableSchema{"T": schema}, newConfig(opts))
+	}
+	count := func(db *sql.DB, table string) int {
+		var n int
+		if err := db.QueryRow(`SELECT count(*) FROM ` + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	_, err := load()
+	if err == nil || !strings.Contains(err.Error(), "T.csv line 3: wrong number of fields") || !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("default error = %v", err)
+	}
+
+	var report Report
+	db, err := load(WithRowErrors(SkipRowErrors), WithReport(&report))
+	if err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	if n := count(db, "T"); n != 2 {
+		t.Errorf("skip loaded %d rows, want 2", n)
+	}
+	want := []struct {
+		line int
+		raw  string
+	}{{3, "2,3"}, {4, `3,"4"5,y`}, {6, "1,6,w"}}
+	if len(report.Rows) != len(want) {
+		t.Fatalf("report.Rows = %+v", report.Rows)
+	}
+	for i, w := range want {
+		if r := report.Rows[i]; r.Table != "T" || r.File != "T.csv" || r.Line != w.line || r.Raw != w.raw || r.Err == "" {
+			t.Errorf("report.Rows[%d] = %+v, want line %d raw %q", i, r, w.line, w.raw)
+		}
+	}
+	if !strings.Contains(report.Rows[1].Err, "column 5") {
+		t.Errorf("bare quote error = %q, want its column", report.Rows[1].Err)
+	}
+
+	db, err = load(WithRowErrors(RejectRowErrors))
+	if err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if n := count(db, "T"); n != 2 {
+		t.Errorf("reject loaded %d rows, want 2", n)
+	}
+	var line int
+	var raw string
+	if err := db.QueryRow(`SELECT LINE, RAW FROM NASR_REJECT WHERE TABLE_NAME = 'T' AND FILE = 'T.csv' ORDER BY LINE DESC`).Scan(&line, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(db, rejectTable); n != 3 || line != 6 || raw != "1,6,w" {
+		t.Errorf("NASR_REJECT has %d rows, last line %d %q", n, line, raw)
+	}
+}
+
+func TestLoadCSV_Conversions(t *testing.T) {
+	schema := &tableSchema{name: "T", columns: []columnDef{
+		{name: "ELEV", dataType: "REAL", nullable: true},
+		{name: "RWY", dataType: "TEXT", nullable: true},
+	}}
+	csvData := "ELEV,RWY\n12,9\n1234 FT,09\nabc,9L\nabc,H1\n"
+
+	load := func(opts ...Option) (*sql.DB, *Report) {
+		db, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		db.SetMaxOpenConns(1)
+		createTables, _ := generateDDL(map[string]*tableSchema{"T": schema}, nil)
+		if _, err := db.Exec(createTables[0]); err != nil {
+			t.Fatal(err)
+		}
+		report := &Report{}
+		if err := loadCSV(db, strings.NewReader(csvData), "T.csv", schema, newConfig(append(opts, WithReport(report)))); err != nil {
+			t.Fatalf("loadCSV: %v", err)
+		}
+		return db, report
+	}
+	column := func(db *sql.DB, col string) string {
+		var s string
+		if err := db.QueryRow(`SELECT group_concat(coalesce(` + col + `, 'NULL'), '|') FROM (SELECT ` + col + ` FROM T ORDER BY rowid)`).Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	db, report := load()
+	if got := column(db, "ELEV"); got != "12.0|1234 FT|abc|abc" {
+		t.Errorf("ELEV = %s", got)
+	}
+	if len(report.Conversions) != 1 {
+		t.Fatalf("report.Conversions = %+v", report.Conversions)
+	}
+	if f := report.Conversions[0]; f.Table != "T" || f.Column != "ELEV" || f.Count != 3 ||
+		strings.Join(f.Samples, ",") != "1234 FT,abc" {
+		t.Errorf("conversion = %+v", f)
+	}
+
+	db, report = load(WithNullOnParseFailure())
+	if got := column(db, "ELEV"); got != "12.0|NULL|NULL|NULL" {
+		t.Errorf("ELEV with WithNullOnParseFailure = %s", got)
+	}
+	if len(report.Conversions) != 1 || report.Conversions[0].Count != 3 {
+		t.Errorf("report.Conversions = %+v", report.Conversions)
+	}
+
+	db, report = load(WithParser("T", "ELEV", ParseElevation), WithParser("T", "RWY", ZeroPad(2)))
+	if got := column(db, "ELEV"); got != "12.0|1234.0|abc|abc" {
+		t.Errorf("ELEV with ParseElevation = %s", got)
+	}
+	if got := column(db, "RWY"); got != "09|09|09L|H1" {
+		t.Errorf("RWY with ZeroPad = %s", got)
+	}
+	if len(report.Conversions) != 2 || report.Conversions[0].Count != 2 ||
+		report.Conversions[1].Column != "RWY" || strings.Join(report.Conversions[1].Samples, ",") != "H1" {
+		t.Errorf("report.Conversions = %+v", report.Conversions)
+	}
+}
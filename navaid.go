@@ -0,0 +1,228 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrAmbiguous is returned by lookup functions when an identifier matches
+// more than one row and no reference point was given to choose between them.
+var ErrAmbiguous = errors.New("nasr: ambiguous identifier")
+
+// LatLon is a position in decimal degrees, used as the reference point when
+// resolving identifiers that are not unique.
+type LatLon struct {
+	Lat, Lon float64
+}
+
+// Frequency is a navaid frequency in kHz. NDBs publish FREQ in kHz and VHF
+// navaids in MHz; both are normalized here.
+type Frequency int64
+
+// MHz returns the frequency in MHz.
+func (f Frequency) MHz() float64 { return float64(f) / 1000 }
+
+// String formats VHF frequencies in MHz and lower frequencies in kHz.
+func (f Frequency) String() string {
+	if f == 0 {
+		return ""
+	}
+	if f >= 30000 {
+		return strconv.FormatFloat(f.MHz(), 'f', 2, 64) + " MHz"
+	}
+	return strconv.FormatInt(int64(f), 10) + " kHz"
+}
+
+// parseFrequency converts a NAV_BASE.FREQ value for the given NAV_TYPE.
+func parseFrequency(v sql.NullFloat64, navType string) Frequency {
+	if !v.Valid {
+		return 0
+	}
+	if strings.Contains(navType, "NDB") {
+		return Frequency(math.Round(v.Float64))
+	}
+	return Frequency(math.Round(v.Float64 * 1000))
+}
+
+// Channel is a TACAN/DME channel such as "78X".
+type Channel struct {
+	Number int
+	Band   byte // 'X' or 'Y'
+}
+
+// String returns the channel in FAA notation, or "" for no channel.
+func (c Channel) String() string {
+	if c.Number == 0 {
+		return ""
+	}
+	return strconv.Itoa(c.Number) + string(c.Band)
+}
+
+// parseChannel parses a NAV_BASE.CHAN value. It reports false when the value
+// is empty or not of the form <number><X|Y>.
+func parseChannel(s string) (Channel, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return Channel{}, false
+	}
+	band := s[len(s)-1]
+	if band != 'X' && band != 'Y' {
+		return Channel{}, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 1 || n > 126 {
+		return Channel{}, false
+	}
+	return Channel{n, band}, true
+}
+
+// ServiceVolume is a standard service volume from NAV_BASE.DME_SSV.
+type ServiceVolume string
+
+const (
+	SSVTerminal ServiceVolume = "T"
+	SSVLow      ServiceVolume = "L"
+	SSVHigh     ServiceVolume = "H"
+	SSVDMEHigh  ServiceVolume = "DH"
+	SSVDMELow   ServiceVolume = "DL"
+	SSVVORHigh  ServiceVolume = "VH"
+	SSVVORLow   ServiceVolume = "VL"
+)
+
+// Navaid is a NAV_BASE row with its checkpoints and remarks.
+type Navaid struct {
+	ID      string // NAV_ID
+	Type    string // NAV_TYPE, e.g. "VORTAC" or "NDB"
+	City    string
+	State   string // STATE_CODE
+	Country string // COUNTRY_CODE
+	Name    string
+	Status  string // NAV_STATUS
+
+	Lat, Lon  float64
+	Elevation sql.NullFloat64 // ELEV, feet MSL
+	MagVar    sql.NullFloat64 // degrees, east positive
+
+	// TACANLat and TACANLon are the TACAN/DME antenna position when it is
+	// published separately from the navaid position.
+	TACANLat, TACANLon sql.NullFloat64
+
+	Frequency     Frequency
+	Channel       Channel
+	ServiceVolume ServiceVolume // DME_SSV
+	LowARTCC      string        // LOW_ALT_ARTCC_ID
+	HighARTCC     string        // HIGH_ALT_ARTCC_ID
+
+	Checkpoints []Checkpoint
+	Remarks     []Remark
+}
+
+// Checkpoint is a NAV_CKPT VOR receiver checkpoint.
+type Checkpoint struct {
+	Altitude    sql.NullFloat64 // feet MSL, for airborne checkpoints
+	Bearing     sql.NullFloat64 // BRG, degrees magnetic from the navaid
+	AirGround   string          // AIR_GND_CODE
+	Description string          // CHK_DESC
+	AirportID   string          // ARPT_ID
+	State       string          // STATE_CHK_CODE
+}
+
+func navaidOf(r record) Navaid {
+	n := Navaid{
+		ID:            r.str("NAV_ID"),
+		Type:          r.str("NAV_TYPE"),
+		City:          r.str("CITY"),
+		State:         r.str("STATE_CODE"),
+		Country:       r.str("COUNTRY_CODE"),
+		Name:          r.str("NAME"),
+		Status:        r.str("NAV_STATUS"),
+		Lat:           r.float("LAT_DECIMAL").Float64,
+		Lon:           r.float("LONG_DECIMAL").Float64,
+		Elevation:     r.float("ELEV"),
+		MagVar:        signedMagVar(r.float("MAG_VARN"), r.str("MAG_VARN_HEMIS")),
+		TACANLat:      r.float("TACAN_DME_LAT_DECIMAL"),
+		TACANLon:      r.float("TACAN_DME_LONG_DECIMAL"),
+		ServiceVolume: ServiceVolume(r.str("DME_SSV")),
+		LowARTCC:      r.str("LOW_ALT_ARTCC_ID"),
+		HighARTCC:     r.str("HIGH_ALT_ARTCC_ID"),
+	}
+	n.Frequency = parseFrequency(r.float("FREQ"), n.Type)
+	n.Channel, _ = parseChannel(r.str("CHAN"))
+	return n
+}
+
+// LookupNavaids returns every navaid with the given NAV_ID, with checkpoints
+// and remarks.
+func LookupNavaids(db *sql.DB, ident string) ([]Navaid, error) {
+	recs, err := queryRecords(db, `SELECT * FROM "NAV_BASE" WHERE "NAV_ID" = ? ORDER BY rowid`, ident)
+	if err != nil {
+		return nil, fmt.Errorf("lookup navaid %s: %w", ident, err)
+	}
+	navs := make([]Navaid, len(recs))
+	for i, r := range recs {
+		navs[i] = navaidOf(r)
+		if err := loadNavaidDetails(db, &navs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return navs, nil
+}
+
+// LookupNavaid returns the navaid with the given NAV_ID. NAV_ID alone is not
+// unique; when several navaids share it the one nearest to near is returned.
+// If near is nil and the ident is ambiguous the error wraps ErrAmbiguous.
+func LookupNavaid(db *sql.DB, ident string, near *LatLon) (*Navaid, error) {
+	navs, err := LookupNavaids(db, ident)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(navs) == 0:
+		return nil, fmt.Errorf("navaid %s: %w", ident, ErrNotFound)
+	case len(navs) == 1:
+		return &navs[0], nil
+	case near == nil:
+		return nil, fmt.Errorf("navaid %s matches %d navaids: %w", ident, len(navs), ErrAmbiguous)
+	}
+	best := 0
+	for i := range navs {
+		if DistanceNM(near.Lat, near.Lon, navs[i].Lat, navs[i].Lon) <
+			DistanceNM(near.Lat, near.Lon, navs[best].Lat, navs[best].Lon) {
+			best = i
+		}
+	}
+	return &navs[best], nil
+}
+
+func loadNavaidDetails(db *sql.DB, n *Navaid) error {
+	key := []interface{}{n.ID, n.Type, n.City, n.Country}
+	const where = `WHERE "NAV_ID" = ? AND "NAV_TYPE" = ? AND "CITY" = ? AND "COUNTRY_CODE" = ? ORDER BY rowid`
+
+	ckpts, err := queryRecords(db, `SELECT * FROM "NAV_CKPT" `+where, key...)
+	if err != nil {
+		return fmt.Errorf("checkpoints for %s: %w", n.ID, err)
+	}
+	for _, c := range ckpts {
+		n.Checkpoints = append(n.Checkpoints, Checkpoint{
+			Altitude:    c.float("ALTITUDE"),
+			Bearing:     c.float("BRG"),
+			AirGround:   c.str("AIR_GND_CODE"),
+			Description: c.str("CHK_DESC"),
+			AirportID:   c.str("ARPT_ID"),
+			State:       c.str("STATE_CHK_CODE"),
+		})
+	}
+
+	rmks, err := queryRecords(db, `SELECT * FROM "NAV_RMK" `+where, key...)
+	if err != nil {
+		return fmt.Errorf("remarks for %s: %w", n.ID, err)
+	}
+	for _, r := range rmks {
+		n.Remarks = append(n.Remarks, remarkOf(r))
+	}
+	return nil
+}
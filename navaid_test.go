@@ -0,0 +1,99 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Channel
+		ok   bool
+	}{
+		{"78X", Channel{78, 'X'}, true},
+		{"112Y", Channel{112, 'Y'}, true},
+		{"", Channel{}, false},
+		{"78", Channel{}, false},
+		{"0X", Channel{}, false},
+		{"127X", Channel{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseChannel(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseChannel(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseFrequency(t *testing.T) {
+	if f := parseFrequency(sql.NullFloat64{Float64: 113.1, Valid: true}, "VORTAC"); f != 113100 || f.String() != "113.10 MHz" {
+		t.Errorf("VORTAC 113.1 = %d (%s)", f, f)
+	}
+	if f := parseFrequency(sql.NullFloat64{Float64: 365, Valid: true}, "NDB"); f != 365 || f.String() != "365 kHz" {
+		t.Errorf("NDB 365 = %d (%s)", f, f)
+	}
+	if f := parseFrequency(sql.NullFloat64{}, "VOR"); f != 0 {
+		t.Errorf("NULL frequency = %d", f)
+	}
+}
+
+func TestLookupNavaid(t *testing.T) {
+	db := openTestDB(t)
+
+	n, err := LookupNavaid(db, "ADW", nil)
+	if err != nil {
+		t.Fatalf("LookupNavaid(ADW): %v", err)
+	}
+	if n.Type != "VORTAC" || n.Frequency != 113100 || n.Channel != (Channel{78, 'X'}) || n.ServiceVolume != SSVLow {
+		t.Errorf("ADW = %s %s %s %s", n.Type, n.Frequency, n.Channel, n.ServiceVolume)
+	}
+	if n.MagVar.Float64 != -10 {
+		t.Errorf("ADW MagVar = %v, want -10 (west)", n.MagVar.Float64)
+	}
+	if !n.TACANLat.Valid || len(n.Remarks) == 0 {
+		t.Errorf("ADW TACAN position %v, %d remarks", n.TACANLat, len(n.Remarks))
+	}
+
+	if n, err := LookupNavaid(db, "AA", nil); err != nil || n.Frequency != 365 {
+		t.Errorf("LookupNavaid(AA) = %v, %v; want 365 kHz NDB", n, err)
+	}
+	if _, err := LookupNavaid(db, "NOPE", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupNavaid(NOPE) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLookupNavaid_Ambiguous(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	stmts := []string{
+		`CREATE TABLE NAV_BASE (NAV_ID TEXT, NAV_TYPE TEXT, CITY TEXT, COUNTRY_CODE TEXT,
+  LAT_DECIMAL REAL, LONG_DECIMAL REAL, FREQ REAL)`,
+		`CREATE TABLE NAV_CKPT (NAV_ID TEXT, NAV_TYPE TEXT, CITY TEXT, COUNTRY_CODE TEXT)`,
+		`CREATE TABLE NAV_RMK (NAV_ID TEXT, NAV_TYPE TEXT, CITY TEXT, COUNTRY_CODE TEXT)`,
+		`INSERT INTO NAV_BASE VALUES
+  ('XY', 'NDB', 'EAST', 'US', 40, -75, 400),
+  ('XY', 'VOR', 'WEST', 'US', 40, -120, 112.0)`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("exec: %v\n%s", err, s)
+		}
+	}
+
+	if _, err := LookupNavaid(db, "XY", nil); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("LookupNavaid(XY, nil) error = %v, want ErrAmbiguous", err)
+	}
+	n, err := LookupNavaid(db, "XY", &LatLon{43.56, -116.22})
+	if err != nil {
+		t.Fatalf("LookupNavaid(XY, near KBOI): %v", err)
+	}
+	if n.City != "WEST" || n.Frequency != 112000 {
+		t.Errorf("nearest XY = %s %s, want WEST 112.00 MHz", n.City, n.Frequency)
+	}
+}
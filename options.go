@@ -0,0 +1,102 @@
+package nasr
+
+// Option configures optional behavior of Extract.
+type Option func(*config)
+
+type config struct {
+	fullTextSearch bool
+	spatialIndex   bool
+	geoPackage     bool
+	strictColumns  bool
+	rowErrors      RowErrorMode
+	report         *Report
+
+	parsers            map[[2]string]Parser // by table and column
+	nullOnParseFailure bool
+	rules              []Rule
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{rules: DefaultRules()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithFullTextSearch builds an FTS5 index over remark tables and free-text
+// columns after the data is loaded. See Search.
+func WithFullTextSearch() Option {
+	return func(c *config) { c.fullTextSearch = true }
+}
+
+// WithSpatialIndex builds an R*Tree index for every table with
+// LAT_DECIMAL/LONG_DECIMAL columns. See Within and Nearest.
+func WithSpatialIndex() Option {
+	return func(c *config) { c.spatialIndex = true }
+}
+
+// WithGeoPackage writes the output as an OGC GeoPackage. Point, line and
+// polygon feature tables (airports, runways, ARTCC boundaries and so on) are
+// added next to the NASR tables and keep their original NASR columns.
+func WithGeoPackage() Option {
+	return func(c *config) { c.geoPackage = true }
+}
+
+// WithReport has Extract record load diagnostics in r. See Report.
+func WithReport(r *Report) Option {
+	return func(c *config) { c.report = r }
+}
+
+// WithStrictColumns makes Extract fail when a data file's header has columns
+// the structure file does not define or lacks columns it does. By default
+// such files are loaded by column name with a warning.
+func WithStrictColumns() Option {
+	return func(c *config) { c.strictColumns = true }
+}
+
+// RowErrorMode selects what Extract does with a data file record it cannot
+// load: a malformed CSV line or a row the database rejects.
+type RowErrorMode int
+
+const (
+	// FailOnRowError stops Extract with an error naming the file and line.
+	// This is the default.
+	FailOnRowError RowErrorMode = iota
+	// SkipRowErrors logs a warning and leaves the record out.
+	SkipRowErrors
+	// RejectRowErrors leaves the record out and copies it, with its file,
+	// line and error, to the NASR_REJECT table.
+	RejectRowErrors
+)
+
+// WithRowErrors sets how Extract handles records it cannot load. Skipped and
+// rejected records are also listed in the Report, if one is given.
+func WithRowErrors(mode RowErrorMode) Option {
+	return func(c *config) { c.rowErrors = mode }
+}
+
+// WithParser has Extract convert the values of a table's column with p
+// instead of the default conversion. Registering a parser for the same
+// column again replaces it.
+func WithParser(table, column string, p Parser) Option {
+	return func(c *config) {
+		if c.parsers == nil {
+			c.parsers = map[[2]string]Parser{}
+		}
+		c.parsers[[2]string{table, column}] = p
+	}
+}
+
+// WithNullOnParseFailure stores NULL for values that do not parse: values
+// of REAL columns that are not numbers, and values a Parser rejects. By
+// default they are stored as text. Values of NOT NULL columns are always
+// stored as text.
+func WithNullOnParseFailure() Option {
+	return func(c *config) { c.nullOnParseFailure = true }
+}
+
+// WithRules adds value normalization rules, applied after DefaultRules.
+func WithRules(rules ...Rule) Option {
+	return func(c *config) { c.rules = append(c.rules, rules...) }
+}
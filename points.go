@@ -0,0 +1,128 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// namedPoint is a located fix or navaid.
+type namedPoint struct {
+	ident    string
+	region   string // ICAO_REGION_CODE for fixes
+	navType  string // NAV_TYPE for navaids
+	lat, lon float64
+}
+
+// pointIndex resolves fix and navaid identifiers to coordinates. Identifiers
+// are not unique, so lookups take a reference point to pick the closest.
+type pointIndex struct {
+	fixes   map[string][]namedPoint
+	navaids map[string][]namedPoint
+}
+
+func loadPointIndex(db *sql.DB) (*pointIndex, error) {
+	idx := &pointIndex{
+		fixes:   make(map[string][]namedPoint),
+		navaids: make(map[string][]namedPoint),
+	}
+	load := func(query string, into map[string][]namedPoint, qualifier func(*namedPoint) *string) error {
+		rows, err := db.Query(query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var p namedPoint
+			var qual sql.NullString
+			if err := rows.Scan(&p.ident, &qual, &p.lat, &p.lon); err != nil {
+				return err
+			}
+			*qualifier(&p) = qual.String
+			into[p.ident] = append(into[p.ident], p)
+		}
+		return rows.Err()
+	}
+	err := load(`SELECT "FIX_ID", "ICAO_REGION_CODE", "LAT_DECIMAL", "LONG_DECIMAL" FROM "FIX_BASE"
+WHERE typeof("LAT_DECIMAL") = 'real' AND typeof("LONG_DECIMAL") = 'real'`, idx.fixes,
+		func(p *namedPoint) *string { return &p.region })
+	if err != nil {
+		return nil, fmt.Errorf("load fixes: %w", err)
+	}
+	err = load(`SELECT "NAV_ID", "NAV_TYPE", "LAT_DECIMAL", "LONG_DECIMAL" FROM "NAV_BASE"
+WHERE typeof("LAT_DECIMAL") = 'real' AND typeof("LONG_DECIMAL") = 'real'`, idx.navaids,
+		func(p *namedPoint) *string { return &p.navType })
+	if err != nil {
+		return nil, fmt.Errorf("load navaids: %w", err)
+	}
+	return idx, nil
+}
+
+// isNavaidType reports whether a point type column (e.g. FROM_PT_TYPE or
+// POINT_TYPE) names a navaid rather than a fix.
+func isNavaidType(t string) bool {
+	switch strings.TrimSpace(t) {
+	case "NDB", "NDB/DME", "VOR", "VOR/DME", "VORTAC", "TACAN", "DME", "VOT", "NAVAID":
+		return true
+	}
+	return false
+}
+
+// pointKind selects which identifiers resolve searches.
+type pointKind int
+
+const (
+	pointAny pointKind = iota
+	pointFix
+	pointNavaid
+)
+
+// pointKindOf maps a point type column (e.g. FROM_PT_TYPE or POINT_TYPE) to
+// the kind of point it names.
+func pointKindOf(t string) pointKind {
+	if isNavaidType(t) {
+		return pointNavaid
+	}
+	if strings.TrimSpace(t) == "" {
+		return pointAny
+	}
+	return pointFix
+}
+
+// resolve looks up ident among the given kind of points, falling back to the
+// other kind when nothing matches. A non-empty region restricts fix
+// candidates. When several candidates remain the one closest to near is
+// returned; with no reference point the first is returned.
+func (idx *pointIndex) resolve(ident, region string, kind pointKind, near *namedPoint) (namedPoint, bool) {
+	var passes [][]namedPoint
+	switch kind {
+	case pointFix:
+		passes = [][]namedPoint{idx.fixes[ident], idx.navaids[ident]}
+	case pointNavaid:
+		passes = [][]namedPoint{idx.navaids[ident], idx.fixes[ident]}
+	default:
+		passes = [][]namedPoint{append(append([]namedPoint(nil), idx.fixes[ident]...), idx.navaids[ident]...)}
+	}
+	for _, pass := range passes {
+		var cands []namedPoint
+		for _, p := range pass {
+			if region != "" && p.region != "" && p.region != region {
+				continue
+			}
+			cands = append(cands, p)
+		}
+		if len(cands) == 0 {
+			continue
+		}
+		best := cands[0]
+		if near != nil {
+			for _, c := range cands[1:] {
+				if DistanceNM(near.lat, near.lon, c.lat, c.lon) < DistanceNM(near.lat, near.lon, best.lat, best.lon) {
+					best = c
+				}
+			}
+		}
+		return best, true
+	}
+	return namedPoint{}, false
+}
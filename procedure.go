@@ -0,0 +1,327 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProcedureKind distinguishes departure procedures from arrivals.
+type ProcedureKind int
+
+const (
+	ProcedureDeparture ProcedureKind = iota // DP_BASE/DP_RTE
+	ProcedureArrival                        // STAR_BASE/STAR_RTE
+)
+
+func (k ProcedureKind) String() string {
+	if k == ProcedureArrival {
+		return "STAR"
+	}
+	return "DP"
+}
+
+// Procedure is a departure procedure or standard terminal arrival with its
+// body and transition portions.
+type Procedure struct {
+	Kind           ProcedureKind
+	Name           string // DP_NAME or ARRIVAL_NAME, e.g. "ACCRA"
+	Amendment      string // AMENDMENT_NO, e.g. "FIVE"
+	ComputerCode   string // e.g. "ACCRA5.ACCRA" or "AALAN.BLAID2"
+	ARTCC          string
+	RNAV           bool
+	ServedAirports []string // SERVED_ARPT
+
+	Portions []ProcedurePortion
+}
+
+// ProcedurePortion is one body or transition of a procedure.
+type ProcedurePortion struct {
+	Transition     bool   // ROUTE_PORTION_TYPE is TRANSITION rather than BODY
+	Name           string // ROUTE_NAME, e.g. "FANZI-ACCRA" or "WOOLY TRANSITION"
+	BodySeq        int    // BODY_SEQ
+	TransitionCode string // TRANSITION_COMPUTER_CODE, e.g. "ARSNL5.WOOLY"
+
+	// Points are in flight order.
+	Points []ProcedurePoint
+
+	// Runways are the airports and runways the portion serves, from the
+	// ARPT_RWY_ASSOC of its points.
+	Runways []RunwayAssoc
+}
+
+// ProcedurePoint is one point of a procedure portion, resolved against
+// FIX_BASE and NAV_BASE.
+type ProcedurePoint struct {
+	Ident    string
+	Region   string // ICAO_REGION_CODE
+	Type     string // POINT_TYPE, e.g. "WP", "RP" or "VORTAC"
+	Lat, Lon float64
+	Resolved bool
+}
+
+// IsNavaid reports whether the point's Type names a navaid, such as
+// "VORTAC", rather than a fix.
+func (p ProcedurePoint) IsNavaid() bool { return isNavaidType(p.Type) }
+
+// RunwayAssoc is an airport and runway from an ARPT_RWY_ASSOC list. Runway is
+// empty when all runways of the airport are served.
+type RunwayAssoc struct {
+	Airport string
+	Runway  string
+}
+
+// String returns the association in ARPT_RWY_ASSOC form, e.g. "MKE/01L".
+func (a RunwayAssoc) String() string {
+	if a.Runway == "" {
+		return a.Airport
+	}
+	return a.Airport + "/" + a.Runway
+}
+
+// ParseRunwayAssoc parses an ARPT_RWY_ASSOC value such as
+// "57C/08, 57C/26, BUU/11, ENW".
+func ParseRunwayAssoc(s string) []RunwayAssoc {
+	var out []RunwayAssoc
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		apt, rwy, _ := strings.Cut(item, "/")
+		out = append(out, RunwayAssoc{strings.TrimSpace(apt), strings.TrimSpace(rwy)})
+	}
+	return out
+}
+
+// serves reports whether the portion serves the runway, given either as a
+// runway end id ("01L") or as airport and runway ("MKE/01L").
+func (pp *ProcedurePortion) serves(runway string) bool {
+	apt, rwy, qualified := strings.Cut(runway, "/")
+	if !qualified {
+		apt, rwy = "", runway
+	}
+	for _, a := range pp.Runways {
+		if apt != "" && a.Airport != apt {
+			continue
+		}
+		if a.Runway == "" || a.Runway == rwy {
+			return true
+		}
+	}
+	return false
+}
+
+// Body returns the body portions, ordered by BODY_SEQ. Procedures that serve
+// several runways or airports may have more than one body.
+func (p *Procedure) Body() []ProcedurePortion {
+	var out []ProcedurePortion
+	for _, pp := range p.Portions {
+		if !pp.Transition {
+			out = append(out, pp)
+		}
+	}
+	return out
+}
+
+// Transitions returns the transition portions.
+func (p *Procedure) Transitions() []ProcedurePortion {
+	var out []ProcedurePortion
+	for _, pp := range p.Portions {
+		if pp.Transition {
+			out = append(out, pp)
+		}
+	}
+	return out
+}
+
+// transition finds a transition by computer code, by the fix that names it
+// (the last point of a DP transition or the first of a STAR transition), or
+// by ROUTE_NAME.
+func (p *Procedure) transition(name string) *ProcedurePortion {
+	for i := range p.Portions {
+		pp := &p.Portions[i]
+		if !pp.Transition {
+			continue
+		}
+		if pp.TransitionCode == name || pp.Name == name {
+			return pp
+		}
+		if n := len(pp.Points); n > 0 {
+			if (p.Kind == ProcedureDeparture && pp.Points[n-1].Ident == name) ||
+				(p.Kind == ProcedureArrival && pp.Points[0].Ident == name) {
+				return pp
+			}
+		}
+		code := strings.Split(pp.TransitionCode, ".")
+		if (p.Kind == ProcedureDeparture && code[len(code)-1] == name) || (p.Kind == ProcedureArrival && code[0] == name) {
+			return pp
+		}
+	}
+	return nil
+}
+
+// Expand returns the procedure's points in flight order for a transition and
+// runway. The transition may be given by computer code (e.g. "ARSNL5.WOOLY"),
+// by its fix or by its route name; empty means no transition. The runway,
+// either "01L" or "MKE/01L", selects the body that serves it; empty selects
+// the first body. A DP is flown body then transition and a STAR transition
+// then body; the shared point is listed once. The error wraps ErrNotFound
+// when the transition or runway is not part of the procedure.
+func (p *Procedure) Expand(transition, runway string) ([]ProcedurePoint, error) {
+	var body *ProcedurePortion
+	for i := range p.Portions {
+		pp := &p.Portions[i]
+		if pp.Transition {
+			continue
+		}
+		if runway == "" || pp.serves(runway) {
+			body = pp
+			break
+		}
+	}
+	if body == nil && runway != "" {
+		return nil, fmt.Errorf("%s does not serve runway %s: %w", p.ComputerCode, runway, ErrNotFound)
+	}
+
+	var trans *ProcedurePortion
+	if transition != "" {
+		if trans = p.transition(transition); trans == nil {
+			return nil, fmt.Errorf("%s has no transition %s: %w", p.ComputerCode, transition, ErrNotFound)
+		}
+	}
+
+	var parts []*ProcedurePortion
+	if p.Kind == ProcedureDeparture {
+		parts = []*ProcedurePortion{body, trans}
+	} else {
+		parts = []*ProcedurePortion{trans, body}
+	}
+	var out []ProcedurePoint
+	for _, pp := range parts {
+		if pp == nil {
+			continue
+		}
+		for _, pt := range pp.Points {
+			if n := len(out); n > 0 && out[n-1].Ident == pt.Ident {
+				continue
+			}
+			out = append(out, pt)
+		}
+	}
+	return out, nil
+}
+
+type procedureTables struct {
+	kind                        ProcedureKind
+	base, rte, codeCol, nameCol string
+}
+
+var (
+	dpTables   = procedureTables{ProcedureDeparture, "DP_BASE", "DP_RTE", "DP_COMPUTER_CODE", "DP_NAME"}
+	starTables = procedureTables{ProcedureArrival, "STAR_BASE", "STAR_RTE", "STAR_COMPUTER_CODE", "ARRIVAL_NAME"}
+)
+
+// LookupProcedure returns the DP or STAR identified by its computer code
+// ("ACCRA5.ACCRA", "AALAN.BLAID2"), by the name and number used in route
+// strings ("ACCRA5", "BLAID2"), or by one of its transition computer codes
+// ("ARSNL5.WOOLY", "SJI.PUCKS4"). It returns ErrNotFound when nothing
+// matches.
+func LookupProcedure(db *sql.DB, ident string) (*Procedure, error) {
+	for _, t := range []procedureTables{dpTables, starTables} {
+		pattern := ident + ".%"
+		if t.kind == ProcedureArrival {
+			pattern = "%." + ident
+		}
+		var code string
+		err := db.QueryRow(fmt.Sprintf(`SELECT %[2]q FROM %[1]q WHERE %[2]q = ?1
+UNION ALL SELECT %[2]q FROM %[1]q WHERE %[2]q LIKE ?2
+UNION ALL SELECT %[2]q FROM %[3]q WHERE "TRANSITION_COMPUTER_CODE" = ?1 LIMIT 1`,
+			t.base, t.codeCol, t.rte), ident, pattern).Scan(&code)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lookup procedure %s: %w", ident, err)
+		}
+		return loadProcedure(db, t, code)
+	}
+	return nil, fmt.Errorf("procedure %s: %w", ident, ErrNotFound)
+}
+
+func loadProcedure(db *sql.DB, t procedureTables, code string) (*Procedure, error) {
+	p := &Procedure{Kind: t.kind, ComputerCode: code}
+	base, err := queryRecords(db, fmt.Sprintf(`SELECT * FROM %q WHERE %q = ? LIMIT 1`, t.base, t.codeCol), code)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", t.base, code, err)
+	}
+	if len(base) > 0 {
+		b := base[0]
+		p.Name = b.str(t.nameCol)
+		p.Amendment = b.str("AMENDMENT_NO")
+		p.ARTCC = b.str("ARTCC")
+		p.RNAV = b.flag("RNAV_FLAG")
+		p.ServedAirports = strings.Fields(b.str("SERVED_ARPT"))
+	}
+
+	// DP_RTE and STAR_RTE list each portion's points from its end backwards,
+	// so descending POINT_SEQ is flight order.
+	rows, err := queryRecords(db, fmt.Sprintf(`SELECT * FROM %q WHERE %q = ?
+ORDER BY "ROUTE_PORTION_TYPE", "BODY_SEQ", "TRANSITION_COMPUTER_CODE", "ROUTE_NAME", "POINT_SEQ" DESC`,
+		t.rte, t.codeCol), code)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", t.rte, code, err)
+	}
+	idx, err := loadPointIndex(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev *namedPoint
+	for _, r := range rows {
+		pp := ProcedurePortion{
+			Transition:     r.str("ROUTE_PORTION_TYPE") == "TRANSITION",
+			Name:           r.str("ROUTE_NAME"),
+			BodySeq:        int(r.float("BODY_SEQ").Float64),
+			TransitionCode: r.str("TRANSITION_COMPUTER_CODE"),
+		}
+		n := len(p.Portions)
+		if n == 0 || !samePortion(&p.Portions[n-1], &pp) {
+			p.Portions = append(p.Portions, pp)
+			n++
+			prev = nil
+		}
+		cur := &p.Portions[n-1]
+
+		pt := ProcedurePoint{
+			Ident:  r.str("POINT"),
+			Region: r.str("ICAO_REGION_CODE"),
+			Type:   r.str("POINT_TYPE"),
+		}
+		if np, ok := idx.resolve(pt.Ident, pt.Region, pointKindOf(pt.Type), prev); ok {
+			pt.Lat, pt.Lon, pt.Resolved = np.lat, np.lon, true
+			prev = &np
+		}
+		cur.Points = append(cur.Points, pt)
+		for _, a := range ParseRunwayAssoc(r.str("ARPT_RWY_ASSOC")) {
+			if !containsAssoc(cur.Runways, a) {
+				cur.Runways = append(cur.Runways, a)
+			}
+		}
+	}
+	return p, nil
+}
+
+func samePortion(a, b *ProcedurePortion) bool {
+	return a.Transition == b.Transition && a.Name == b.Name && a.BodySeq == b.BodySeq && a.TransitionCode == b.TransitionCode
+}
+
+func containsAssoc(list []RunwayAssoc, a RunwayAssoc) bool {
+	for _, x := range list {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
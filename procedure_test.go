@@ -0,0 +1,141 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRunwayAssoc(t *testing.T) {
+	got := ParseRunwayAssoc("57C/08, 57C/26, BUU/11, ENW")
+	want := []RunwayAssoc{{"57C", "08"}, {"57C", "26"}, {"BUU", "11"}, {"ENW", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRunwayAssoc = %v, want %v", got, want)
+	}
+	if got := ParseRunwayAssoc(""); got != nil {
+		t.Errorf("ParseRunwayAssoc(\"\") = %v", got)
+	}
+	if s := want[0].String() + " " + want[3].String(); s != "57C/08 ENW" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func pointIdents(pts []ProcedurePoint) []string {
+	var out []string
+	for _, p := range pts {
+		out = append(out, p.Ident)
+	}
+	return out
+}
+
+func TestLookupProcedure(t *testing.T) {
+	db := openTestDB(t)
+
+	p, err := LookupProcedure(db, "ACCRA5")
+	if err != nil {
+		t.Fatalf("LookupProcedure(ACCRA5): %v", err)
+	}
+	if p.Kind != ProcedureDeparture || p.ComputerCode != "ACCRA5.ACCRA" || p.Amendment != "FIVE" || !p.RNAV {
+		t.Errorf("ACCRA5 = %+v", p)
+	}
+	if len(p.Body()) != 1 || !containsAssoc(p.Body()[0].Runways, RunwayAssoc{"MKE", "01L"}) {
+		t.Errorf("ACCRA5 body = %+v", p.Body())
+	}
+	if pts, err := p.Expand("", "MKE/01L"); err != nil || len(pts) == 0 {
+		t.Errorf("Expand(MKE/01L) = %v, %v", pts, err)
+	}
+	if _, err := p.Expand("", "ENW/99"); err != nil {
+		t.Errorf("Expand(ENW/99): ENW serves all runways, got %v", err)
+	}
+	if _, err := p.Expand("", "XYZ/99"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expand(XYZ/99) error = %v, want ErrNotFound", err)
+	}
+
+	// A transition computer code finds its procedure.
+	p, err = LookupProcedure(db, "SJI.PUCKS4")
+	if err != nil {
+		t.Fatalf("LookupProcedure(SJI.PUCKS4): %v", err)
+	}
+	if p.Kind != ProcedureArrival || p.ComputerCode != "BAYYY.PUCKS4" || len(p.Transitions()) != 1 {
+		t.Fatalf("SJI.PUCKS4 = %+v", p)
+	}
+	pts, err := p.Expand("SJI.PUCKS4", "")
+	if err != nil {
+		t.Fatalf("Expand(SJI.PUCKS4): %v", err)
+	}
+	if want := []string{"JEPEG", "BLEAU"}; !reflect.DeepEqual(pointIdents(pts), want) {
+		t.Errorf("STAR transition then body = %v, want %v", pointIdents(pts), want)
+	}
+	if _, err := p.Expand("NOPE", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expand(NOPE) error = %v, want ErrNotFound", err)
+	}
+
+	if _, err := LookupProcedure(db, "NOPE9"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupProcedure(NOPE9) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProcedure_FlightOrder(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	stmts := []string{
+		`CREATE TABLE FIX_BASE (FIX_ID TEXT, ICAO_REGION_CODE TEXT, LAT_DECIMAL REAL, LONG_DECIMAL REAL)`,
+		`CREATE TABLE NAV_BASE (NAV_ID TEXT, NAV_TYPE TEXT, LAT_DECIMAL REAL, LONG_DECIMAL REAL)`,
+		`CREATE TABLE DP_BASE (DP_NAME TEXT, AMENDMENT_NO TEXT, ARTCC TEXT, RNAV_FLAG TEXT, DP_COMPUTER_CODE TEXT, SERVED_ARPT TEXT)`,
+		`CREATE TABLE DP_RTE (DP_COMPUTER_CODE TEXT, ROUTE_PORTION_TYPE TEXT, ROUTE_NAME TEXT, BODY_SEQ REAL,
+  TRANSITION_COMPUTER_CODE TEXT, POINT_SEQ REAL, POINT TEXT, ICAO_REGION_CODE TEXT, POINT_TYPE TEXT, ARPT_RWY_ASSOC TEXT)`,
+		`INSERT INTO FIX_BASE VALUES ('AAAAA', 'K1', 40, -100), ('BBBBB', 'K1', 40, -99), ('CCCCC', 'K1', 40, -98)`,
+		`INSERT INTO NAV_BASE VALUES ('XYZ', 'VORTAC', 40, -97)`,
+		`INSERT INTO DP_BASE VALUES ('TEST', 'ONE', 'ZDV', 'N', 'TEST1.CCCCC', 'APT')`,
+		// Each portion is listed from its end backwards.
+		`INSERT INTO DP_RTE VALUES
+  ('TEST1.CCCCC', 'BODY', 'NORTH', 1, NULL, 10, 'CCCCC', 'K1', 'WP   ', NULL),
+  ('TEST1.CCCCC', 'BODY', 'NORTH', 1, NULL, 20, 'BBBBB', 'K1', 'WP   ', NULL),
+  ('TEST1.CCCCC', 'BODY', 'NORTH', 1, NULL, 30, 'AAAAA', 'K1', 'WP   ', 'APT/36'),
+  ('TEST1.CCCCC', 'BODY', 'SOUTH', 2, NULL, 10, 'CCCCC', 'K1', 'WP   ', NULL),
+  ('TEST1.CCCCC', 'BODY', 'SOUTH', 2, NULL, 20, 'BBBBB', 'K1', 'WP   ', 'APT/18'),
+  ('TEST1.CCCCC', 'TRANSITION', 'XYZ TRANSITION', 1, 'TEST1.XYZ', 10, 'XYZ', NULL, 'VORTAC', NULL),
+  ('TEST1.CCCCC', 'TRANSITION', 'XYZ TRANSITION', 1, 'TEST1.XYZ', 20, 'CCCCC', 'K1', 'WP   ', NULL)`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("exec: %v\n%s", err, s)
+		}
+	}
+
+	p, err := LookupProcedure(db, "TEST1")
+	if err != nil {
+		t.Fatalf("LookupProcedure(TEST1): %v", err)
+	}
+	if len(p.Body()) != 2 || len(p.Transitions()) != 1 {
+		t.Fatalf("portions = %+v", p.Portions)
+	}
+
+	tests := []struct {
+		transition, runway string
+		want               []string
+	}{
+		{"", "", []string{"AAAAA", "BBBBB", "CCCCC"}},
+		{"XYZ", "36", []string{"AAAAA", "BBBBB", "CCCCC", "XYZ"}},
+		{"TEST1.XYZ", "APT/18", []string{"BBBBB", "CCCCC", "XYZ"}},
+	}
+	for _, tt := range tests {
+		pts, err := p.Expand(tt.transition, tt.runway)
+		if err != nil {
+			t.Fatalf("Expand(%q, %q): %v", tt.transition, tt.runway, err)
+		}
+		if got := pointIdents(pts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expand(%q, %q) = %v, want %v", tt.transition, tt.runway, got, tt.want)
+		}
+		for _, pt := range pts {
+			if !pt.Resolved {
+				t.Errorf("%s not resolved", pt.Ident)
+			}
+		}
+	}
+}
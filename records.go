@@ -0,0 +1,53 @@
+package nasr
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned by lookup functions when no row matches.
+var ErrNotFound = errors.New("nasr: not found")
+
+// record is one row keyed by column name, used by the typed lookup APIs.
+type record map[string]interface{}
+
+func queryRecords(db *sql.DB, query string, args ...interface{}) ([]record, error) {
+	cols, rows, err := queryRows(db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]record, len(rows))
+	for i, r := range rows {
+		out[i] = record(rowMap(cols, r))
+	}
+	return out, nil
+}
+
+// str returns a text column, or "" when it is NULL.
+func (r record) str(col string) string {
+	return asString(r[col])
+}
+
+// float returns a numeric column. Values that are NULL or were stored as
+// text because they did not parse are reported as not valid.
+func (r record) float(col string) sql.NullFloat64 {
+	f, ok := asFloat(r[col])
+	return sql.NullFloat64{Float64: f, Valid: ok}
+}
+
+// num returns a numeric value from a column the schema declares as text,
+// such as HPF_SPD_ALT.SPEED_RANGE.
+func (r record) num(col string) sql.NullFloat64 {
+	if f := r.float(col); f.Valid {
+		return f
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(r.str(col)), 64)
+	return sql.NullFloat64{Float64: f, Valid: err == nil}
+}
+
+// flag reports whether a Y/N flag column is "Y".
+func (r record) flag(col string) bool {
+	return r.str(col) == "Y"
+}
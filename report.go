@@ -0,0 +1,56 @@
+package nasr
+
+// Report collects diagnostics about how the CSV data was loaded. Pass one to
+// Extract with WithReport; Extract fills it in as it goes, so it is also
+// useful after Extract fails.
+type Report struct {
+	// Columns lists the data files whose header does not match their
+	// table's columns in the structure file.
+	Columns []ColumnMismatch
+
+	// Rows lists the records skipped or rejected under WithRowErrors.
+	Rows []RowError
+
+	// Conversions lists the columns with values that did not parse.
+	Conversions []ConversionFailure
+
+	// Trimmed counts, per trimmed column, the values that had padding
+	// removed. See Rule.Trim.
+	Trimmed []TrimmedColumn
+}
+
+// ColumnMismatch describes a data file header that does not match the
+// structure file. Values of unknown columns are dropped; missing columns
+// are loaded as NULL.
+type ColumnMismatch struct {
+	Table   string
+	File    string
+	Unknown []string // header columns the structure file does not define
+	Missing []string // structure file columns the header lacks
+}
+
+// RowError is a data file record that could not be loaded.
+type RowError struct {
+	Table string
+	File  string
+	Line  int    // line the record starts on
+	Err   string // why it could not be loaded
+	Raw   string // the record's text, without the line ending
+}
+
+// ConversionFailure counts the values of a column that did not parse: values
+// of a REAL column that are not numbers, or values its Parser rejected.
+type ConversionFailure struct {
+	Table   string
+	Column  string
+	Count   int
+	Samples []string // up to five distinct values
+}
+
+// TrimmedColumn counts the values of a column that had leading or trailing
+// spaces removed.
+type TrimmedColumn struct {
+	Table  string
+	Column string
+	Count  int
+}
@@ -0,0 +1,451 @@
+// Package route parses and expands route strings from a NASR database, such
+// as CDR "Route String", PFR_BASE.ROUTE_STRING or a filed flight plan route:
+//
+//	KABQ DOOKK3 TXO J6 PNH TURKI VKTRY2 KDFW
+//
+// Tokens are classified as airports, fixes, navaids, airways, departure
+// procedures (DPs), arrivals (STARs) or latitude/longitude points, and
+// airways and procedures are expanded into their ordered waypoints.
+package route
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	nasr "github.com/IdahoAvionics/go-nasr"
+)
+
+// Kind is the classification of a route token.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	Airport
+	Fix
+	Navaid
+	Airway
+	Departure // DP, by computer code or its name and number, e.g. "ACCRA5"
+	Arrival   // STAR, e.g. "BLAID2" or "AALAN.BLAID2"
+	LatLon    // e.g. "4530N07045W" or "45N070W"
+	Direct    // "DCT"
+)
+
+var kindNames = [...]string{"UNKNOWN", "AIRPORT", "FIX", "NAVAID", "AIRWAY", "DP", "STAR", "LATLON", "DCT"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Token is one classified element of a route string.
+type Token struct {
+	Text  string
+	Kind  Kind
+	Index int // position in the route string, from 0
+}
+
+// Waypoint is one point of an expanded route.
+type Waypoint struct {
+	Ident    string
+	Kind     Kind // Airport, Fix, Navaid, LatLon, or Unknown when not in the database
+	Lat, Lon float64
+	Resolved bool   // false when the point could not be located
+	Via      string // airway or procedure the point was reached on, or "" when direct
+	Token    int    // index of the token that produced the point
+}
+
+// Problem describes a token that could not be classified or expanded.
+type Problem struct {
+	Token  Token
+	Reason string
+}
+
+// Route is a parsed and expanded route string.
+type Route struct {
+	Tokens     []Token
+	Waypoints  []Waypoint
+	Unresolved []Problem
+}
+
+// Tokenize splits a route string into tokens.
+func Tokenize(s string) []string {
+	return strings.Fields(strings.ToUpper(s))
+}
+
+// Expander classifies and expands route strings against a NASR database.
+// Airways are cached, so an Expander should be reused for many routes.
+type Expander struct {
+	db      *sql.DB
+	airways map[string][]nasr.Airway
+}
+
+// NewExpander returns an Expander that reads from db.
+func NewExpander(db *sql.DB) *Expander {
+	return &Expander{db: db, airways: make(map[string][]nasr.Airway)}
+}
+
+// Expand parses a route string, classifies each token and expands it into
+// waypoints. Problems with individual tokens are reported in Unresolved; the
+// error is only for database failures.
+func (e *Expander) Expand(route string) (*Route, error) {
+	toks, err := e.Classify(route)
+	if err != nil {
+		return nil, err
+	}
+	r := &Route{Tokens: toks}
+	for i, tok := range r.Tokens {
+		var err error
+		switch tok.Kind {
+		case Direct:
+		case Unknown:
+			r.problem(tok, "not found in the database")
+			r.add(Waypoint{Ident: tok.Text, Kind: Unknown, Token: i})
+		case LatLon:
+			lat, lon, _ := ParseLatLon(tok.Text)
+			r.add(Waypoint{Ident: tok.Text, Kind: LatLon, Lat: lat, Lon: lon, Resolved: true, Token: i})
+		case Airport, Fix, Navaid:
+			err = e.addPoint(r, tok, "")
+		case Airway:
+			err = e.expandAirway(r, i)
+		case Departure, Arrival:
+			err = e.expandProcedure(r, i)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Classify returns the classification of each token of a route string.
+func (e *Expander) Classify(route string) ([]Token, error) {
+	texts := Tokenize(route)
+	toks := make([]Token, len(texts))
+	for i, t := range texts {
+		k, err := e.classify(t, i == 0 || i == len(texts)-1)
+		if err != nil {
+			return nil, err
+		}
+		toks[i] = Token{t, k, i}
+	}
+	return toks, nil
+}
+
+func (r *Route) problem(tok Token, format string, args ...interface{}) {
+	r.Unresolved = append(r.Unresolved, Problem{tok, fmt.Sprintf(format, args...)})
+}
+
+// add appends a waypoint, skipping it when it repeats the previous point.
+func (r *Route) add(w Waypoint) {
+	if n := len(r.Waypoints); n > 0 && r.Waypoints[n-1].Ident == w.Ident {
+		return
+	}
+	r.Waypoints = append(r.Waypoints, w)
+}
+
+func (r *Route) last() *Waypoint {
+	for i := len(r.Waypoints) - 1; i >= 0; i-- {
+		if r.Waypoints[i].Resolved {
+			return &r.Waypoints[i]
+		}
+	}
+	return nil
+}
+
+// classify determines a token's kind. The first and last tokens of a route
+// are usually the origin and destination, so airports are preferred there
+// over fixes and navaids with the same ident.
+func (e *Expander) classify(t string, endpoint bool) (Kind, error) {
+	if t == "DCT" {
+		return Direct, nil
+	}
+	if _, _, ok := ParseLatLon(t); ok {
+		return LatLon, nil
+	}
+	type probe struct {
+		kind  Kind
+		query string
+	}
+	airport := probe{Airport, `SELECT 1 FROM "APT_BASE" WHERE "ARPT_ID" = ?1 OR "ICAO_ID" = ?1`}
+	probes := []probe{
+		{Airway, `SELECT 1 FROM "AWY_BASE" WHERE "AWY_ID" = ?1`},
+		{Departure, `SELECT 1 FROM "DP_BASE" WHERE "DP_COMPUTER_CODE" = ?1 OR "DP_COMPUTER_CODE" LIKE ?1 || '.%'
+  OR ?1 IN (SELECT "TRANSITION_COMPUTER_CODE" FROM "DP_RTE")`},
+		{Arrival, `SELECT 1 FROM "STAR_BASE" WHERE "STAR_COMPUTER_CODE" = ?1 OR "STAR_COMPUTER_CODE" LIKE '%.' || ?1
+  OR ?1 IN (SELECT "TRANSITION_COMPUTER_CODE" FROM "STAR_RTE")`},
+		{Navaid, `SELECT 1 FROM "NAV_BASE" WHERE "NAV_ID" = ?1`},
+		{Fix, `SELECT 1 FROM "FIX_BASE" WHERE "FIX_ID" = ?1`},
+	}
+	if endpoint {
+		probes = append([]probe{airport}, probes...)
+	} else {
+		probes = append(probes, airport)
+	}
+	for _, p := range probes {
+		var one int
+		err := e.db.QueryRow(p.query+" LIMIT 1", t).Scan(&one)
+		if err == nil {
+			return p.kind, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return Unknown, fmt.Errorf("classify %s: %w", t, err)
+		}
+	}
+	return Unknown, nil
+}
+
+// locate resolves an ident of the given kind to a position, choosing the
+// candidate nearest to the previous waypoint when it is ambiguous.
+func (e *Expander) locate(ident string, kind Kind, near *nasr.LatLon) (lat, lon float64, ok bool, err error) {
+	switch kind {
+	case Airport:
+		err := e.db.QueryRow(`SELECT "LAT_DECIMAL", "LONG_DECIMAL" FROM "APT_BASE"
+WHERE ("ARPT_ID" = ?1 OR "ICAO_ID" = ?1) AND typeof("LAT_DECIMAL") = 'real' AND typeof("LONG_DECIMAL") = 'real'
+ORDER BY "ARPT_ID" = ?1 DESC
+LIMIT 1`, ident).Scan(&lat, &lon)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, false, nil
+		}
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("locate airport %s: %w", ident, err)
+		}
+		return lat, lon, true, nil
+	case Navaid:
+		n, err := nasr.LookupNavaid(e.db, ident, near)
+		if errors.Is(err, nasr.ErrAmbiguous) {
+			// No reference point yet: take the first.
+			navs, err := nasr.LookupNavaids(e.db, ident)
+			if err != nil {
+				return 0, 0, false, err
+			}
+			return navs[0].Lat, navs[0].Lon, true, nil
+		}
+		if errors.Is(err, nasr.ErrNotFound) {
+			// Fall back to fixes, without falling back again.
+			return e.locate(ident, Unknown, near)
+		}
+		if err != nil {
+			return 0, 0, false, err
+		}
+		return n.Lat, n.Lon, true, nil
+	default:
+		fixes, err := nasr.ResolveFix(e.db, ident, near)
+		if errors.Is(err, nasr.ErrNotFound) {
+			if kind == Fix {
+				return e.locate(ident, Navaid, near)
+			}
+			return 0, 0, false, nil
+		}
+		if err != nil {
+			return 0, 0, false, err
+		}
+		return fixes[0].Lat, fixes[0].Lon, true, nil
+	}
+}
+
+// pointKind returns the kind of an airway or procedure point, from whether
+// its published point type names a navaid.
+func pointKind(navaid bool) Kind {
+	if navaid {
+		return Navaid
+	}
+	return Fix
+}
+
+// near returns the last located waypoint as a reference point.
+func (r *Route) near() *nasr.LatLon {
+	if w := r.last(); w != nil {
+		return &nasr.LatLon{Lat: w.Lat, Lon: w.Lon}
+	}
+	return nil
+}
+
+func (e *Expander) addPoint(r *Route, tok Token, via string) error {
+	return e.addIdent(r, tok.Text, tok.Kind, via, tok.Index)
+}
+
+func (e *Expander) addIdent(r *Route, ident string, kind Kind, via string, index int) error {
+	lat, lon, ok, err := e.locate(ident, kind, r.near())
+	if err != nil {
+		return err
+	}
+	r.add(Waypoint{Ident: ident, Kind: kind, Lat: lat, Lon: lon, Resolved: ok, Via: via, Token: index})
+	return nil
+}
+
+// neighbours returns the point tokens before and after token i, or "" when
+// the adjacent token is not a point. Unknown tokens count as points: an
+// airway or procedure may name points that are missing from FIX_BASE and
+// NAV_BASE.
+func neighbours(r *Route, i int) (prev, next string) {
+	isPoint := func(k Kind) bool { return k == Airport || k == Fix || k == Navaid || k == LatLon || k == Unknown }
+	if i > 0 && isPoint(r.Tokens[i-1].Kind) {
+		prev = r.Tokens[i-1].Text
+	}
+	if i+1 < len(r.Tokens) && isPoint(r.Tokens[i+1].Kind) {
+		next = r.Tokens[i+1].Text
+	}
+	return prev, next
+}
+
+func (e *Expander) lookupAirways(id string) ([]nasr.Airway, error) {
+	if a, ok := e.airways[id]; ok {
+		return a, nil
+	}
+	a, err := nasr.LookupAirways(e.db, id)
+	if err != nil {
+		return nil, err
+	}
+	e.airways[id] = a
+	return a, nil
+}
+
+// expandAirway adds the points of the airway token i between the points
+// before and after it.
+func (e *Expander) expandAirway(r *Route, i int) error {
+	tok := r.Tokens[i]
+	entry, exit := neighbours(r, i)
+	if entry == "" || exit == "" {
+		r.problem(tok, "airway needs an entry and an exit point")
+		return nil
+	}
+	awys, err := e.lookupAirways(tok.Text)
+	if err != nil {
+		return err
+	}
+	gap := false
+	for _, a := range awys {
+		pts, err := a.Expand(entry, exit)
+		if errors.Is(err, nasr.ErrAirwayGap) {
+			gap = true
+		}
+		if err != nil {
+			continue
+		}
+		// The entry and exit are added by their own tokens. They are the
+		// same point when the airway is entered and left at once.
+		if len(pts) < 2 {
+			return nil
+		}
+		for _, p := range pts[1 : len(pts)-1] {
+			r.add(Waypoint{Ident: p.Ident, Kind: pointKind(p.IsNavaid()), Lat: p.Lat, Lon: p.Lon,
+				Resolved: p.Resolved, Via: a.ID, Token: i})
+		}
+		return nil
+	}
+	if gap {
+		r.problem(tok, "airway %s has a gap between %s and %s", tok.Text, entry, exit)
+		return nil
+	}
+	r.problem(tok, "%s and %s are not both on airway %s", entry, exit, tok.Text)
+	return nil
+}
+
+// expandProcedure adds the points of the DP or STAR token i. A DP runs from
+// the preceding airport to the point after it, using the transition that
+// ends there; a STAR runs from the preceding point, using the transition that
+// starts there, to the following airport. A token that is itself a
+// transition computer code selects that transition.
+func (e *Expander) expandProcedure(r *Route, i int) error {
+	tok := r.Tokens[i]
+	prev, next := neighbours(r, i)
+	p, err := nasr.LookupProcedure(e.db, tok.Text)
+	if errors.Is(err, nasr.ErrNotFound) {
+		r.problem(tok, "procedure not found")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	transition := next
+	if p.Kind == nasr.ProcedureArrival {
+		transition = prev
+	}
+	if strings.Contains(tok.Text, ".") && tok.Text != p.ComputerCode {
+		transition = tok.Text
+	}
+	pts, err := p.Expand(transition, "")
+	if errors.Is(err, nasr.ErrNotFound) {
+		// Joined directly to the body, or the transition is not published.
+		pts, err = p.Expand("", "")
+	}
+	if err != nil {
+		return err
+	}
+	if len(pts) == 0 {
+		r.problem(tok, "procedure %s has no points", p.ComputerCode)
+		return nil
+	}
+	for _, pt := range pts {
+		if pt.Ident == next {
+			break
+		}
+		r.add(Waypoint{Ident: pt.Ident, Kind: pointKind(pt.IsNavaid()), Lat: pt.Lat, Lon: pt.Lon,
+			Resolved: pt.Resolved, Via: p.ComputerCode, Token: i})
+	}
+	return nil
+}
+
+// ParseLatLon parses a latitude/longitude route token. Accepted forms are
+// degrees ("45N070W"), degrees and minutes ("4530N07045W") and degrees,
+// minutes and seconds ("453000N0704500W").
+func ParseLatLon(s string) (lat, lon float64, ok bool) {
+	i := strings.IndexAny(s, "NS")
+	if i < 0 || i+1 >= len(s) {
+		return 0, 0, false
+	}
+	latPart, lonPart := s[:i+1], s[i+1:]
+	lat, ok1 := parseAngle(latPart, 2, "NS")
+	lon, ok2 := parseAngle(lonPart, 3, "EW")
+	if !ok1 || !ok2 || lat > 90 || lon > 180 || lat < -90 || lon < -180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// parseAngle parses digits of degrees (degDigits wide), optional minutes and
+// optional seconds followed by a hemisphere letter.
+func parseAngle(s string, degDigits int, hemis string) (float64, bool) {
+	if len(s) < degDigits+1 {
+		return 0, false
+	}
+	h := s[len(s)-1]
+	if !strings.ContainsRune(hemis, rune(h)) {
+		return 0, false
+	}
+	digits := s[:len(s)-1]
+	if len(digits) != degDigits && len(digits) != degDigits+2 && len(digits) != degDigits+4 {
+		return 0, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	deg, _ := strconv.Atoi(digits[:degDigits])
+	v := float64(deg)
+	if len(digits) >= degDigits+2 {
+		m, _ := strconv.Atoi(digits[degDigits : degDigits+2])
+		if m >= 60 {
+			return 0, false
+		}
+		v += float64(m) / 60
+	}
+	if len(digits) == degDigits+4 {
+		sec, _ := strconv.Atoi(digits[degDigits+2:])
+		if sec >= 60 {
+			return 0, false
+		}
+		v += float64(sec) / 3600
+	}
+	if h == 'S' || h == 'W' {
+		v = -v
+	}
+	return v, true
+}
@@ -0,0 +1,154 @@
+package route
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	nasr "github.com/IdahoAvionics/go-nasr"
+	_ "modernc.org/sqlite"
+)
+
+const testZipPath = "../testdata/28DaySubscription_test.zip"
+
+var testDBPath string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "nasr-route-test-*")
+	if err != nil {
+		panic(err)
+	}
+	testDBPath = filepath.Join(dir, "nasr.sqlite3")
+	if err := nasr.Extract(testZipPath, testDBPath); err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", testDBPath)
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestParseLatLon(t *testing.T) {
+	tests := []struct {
+		in       string
+		lat, lon float64
+		ok       bool
+	}{
+		{"45N070W", 45, -70, true},
+		{"4530N07045W", 45.5, -70.75, true},
+		{"453000S0704500E", -45.5, 70.75, true},
+		{"4560N07045W", 0, 0, false},
+		{"KBOI", 0, 0, false},
+		{"45N70W", 0, 0, false},
+	}
+	for _, tt := range tests {
+		lat, lon, ok := ParseLatLon(tt.in)
+		if ok != tt.ok || lat != tt.lat || lon != tt.lon {
+			t.Errorf("ParseLatLon(%q) = %v, %v, %v; want %v, %v, %v", tt.in, lat, lon, ok, tt.lat, tt.lon, tt.ok)
+		}
+	}
+}
+
+func idents(wps []Waypoint) []string {
+	var out []string
+	for _, w := range wps {
+		out = append(out, w.Ident)
+	}
+	return out
+}
+
+func TestExpand(t *testing.T) {
+	e := NewExpander(openTestDB(t))
+
+	tests := []struct {
+		route      string
+		kinds      []Kind
+		waypoints  []string
+		unresolved int
+	}{
+		{
+			route:     "BOI DCT 4330N11615W 45N070W KBOI",
+			kinds:     []Kind{Airport, Direct, LatLon, LatLon, Airport},
+			waypoints: []string{"BOI", "4330N11615W", "45N070W", "KBOI"},
+		},
+		{
+			// Airway expanded in reverse between two points.
+			route:      "FACED A216 RIDLL",
+			kinds:      []Kind{Unknown, Airway, Unknown},
+			waypoints:  []string{"FACED", "GALEE", "HOOVR", "LOEBB", "RIDLL"},
+			unresolved: 2,
+		},
+		{
+			// Airway entered and left at the same point.
+			route:      "MONPI A216 MONPI",
+			kinds:      []Kind{Unknown, Airway, Unknown},
+			waypoints:  []string{"MONPI"},
+			unresolved: 2,
+		},
+		{
+			// STAR entered on its FUDDD transition.
+			route:      "FUDDD ENDEE8",
+			kinds:      []Kind{Unknown, Arrival},
+			waypoints:  []string{"FUDDD", "ENDEE"},
+			unresolved: 1,
+		},
+		{
+			route:      "PTW XYZZY",
+			kinds:      []Kind{Navaid, Unknown},
+			waypoints:  []string{"PTW", "XYZZY"},
+			unresolved: 1,
+		},
+	}
+	for _, tt := range tests {
+		r, err := e.Expand(tt.route)
+		if err != nil {
+			t.Fatalf("Expand(%q): %v", tt.route, err)
+		}
+		var kinds []Kind
+		for _, tok := range r.Tokens {
+			kinds = append(kinds, tok.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tt.kinds) {
+			t.Errorf("Expand(%q) kinds = %v, want %v", tt.route, kinds, tt.kinds)
+		}
+		if got := idents(r.Waypoints); !reflect.DeepEqual(got, tt.waypoints) {
+			t.Errorf("Expand(%q) waypoints = %v, want %v", tt.route, got, tt.waypoints)
+		}
+		if len(r.Unresolved) != tt.unresolved {
+			t.Errorf("Expand(%q) unresolved = %+v, want %d", tt.route, r.Unresolved, tt.unresolved)
+		}
+	}
+}
+
+func TestExpand_LocatesPoints(t *testing.T) {
+	e := NewExpander(openTestDB(t))
+	r, err := e.Expand("KBOI DCT PTW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Waypoints) != 2 {
+		t.Fatalf("waypoints = %+v", r.Waypoints)
+	}
+	for _, w := range r.Waypoints {
+		if !w.Resolved || w.Lat == 0 || w.Lon == 0 {
+			t.Errorf("%s not located: %+v", w.Ident, w)
+		}
+	}
+	if d := nasr.DistanceNM(r.Waypoints[0].Lat, r.Waypoints[0].Lon, 43.56, -116.22); d > 5 {
+		t.Errorf("KBOI located %.1f NM from Boise", d)
+	}
+}
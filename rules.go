@@ -0,0 +1,119 @@
+package nasr
+
+import "strings"
+
+// A Rule normalizes the values of one table column as Extract loads them,
+// before they are converted to the column's type or passed to its Parser.
+// Rules for the same column apply in order; Extract applies DefaultRules
+// and then those given with WithRules.
+type Rule struct {
+	Table  string
+	Column string
+
+	// Apply returns the value to store, or null true to store NULL. It
+	// may be nil for a rule that only sets Nullable or Trim.
+	Apply func(val string) (out string, null bool)
+
+	// Nullable makes the column nullable when the structure file declares
+	// it NOT NULL. Rules that can store NULL set it.
+	Nullable bool
+
+	// Trim has the loader remove leading and trailing spaces from the
+	// column's values before the rules apply, and count the values it
+	// changes in the Report's Trimmed.
+	Trim bool
+}
+
+// SentinelNullRule stores NULL for a placeholder value, such as "NOT
+// ASSIGNED", and makes the column nullable.
+func SentinelNullRule(table, column, sentinel string) Rule {
+	return Rule{
+		Table:  table,
+		Column: column,
+		Apply: func(val string) (string, bool) {
+			return val, val == sentinel
+		},
+		Nullable: true,
+	}
+}
+
+// TrimRule removes leading and trailing spaces, such as the padding of
+// POINT_TYPE values like "WP   ".
+func TrimRule(table, column string) Rule {
+	return Rule{Table: table, Column: column, Trim: true}
+}
+
+// UpperRule converts values to upper case.
+func UpperRule(table, column string) Rule {
+	return FuncRule(table, column, strings.ToUpper)
+}
+
+// FuncRule replaces values with f(value).
+func FuncRule(table, column string, f func(string) string) Rule {
+	return Rule{
+		Table:  table,
+		Column: column,
+		Apply: func(val string) (string, bool) {
+			return f(val), false
+		},
+	}
+}
+
+// DefaultRules returns the rules Extract always applies.
+func DefaultRules() []Rule {
+	return []Rule{
+		// Procedures without a computer code have "NOT ASSIGNED", which
+		// would collide in the unique index on DP_COMPUTER_CODE.
+		SentinelNullRule("DP_BASE", "DP_COMPUTER_CODE", "NOT ASSIGNED"),
+		SentinelNullRule("DP_APT", "DP_COMPUTER_CODE", "NOT ASSIGNED"),
+		SentinelNullRule("DP_RTE", "DP_COMPUTER_CODE", "NOT ASSIGNED"),
+
+		// Point type codes keep the padding of the fixed-width legacy
+		// formats, e.g. "WP   " and "RP   ".
+		TrimRule("AWY_SEG_ALT", "FROM_PT_TYPE"),
+		TrimRule("DP_RTE", "POINT_TYPE"),
+		TrimRule("STAR_RTE", "POINT_TYPE"),
+		TrimRule("FIX_BASE", "FIX_USE_CODE"),
+	}
+}
+
+// columnRules returns the rules for a table's column, in order.
+func columnRules(rules []Rule, table, column string) []Rule {
+	var out []Rule
+	for _, r := range rules {
+		if r.Table == table && r.Column == column {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// applyRules runs a column's rules over a value. It stops at the first
+// rule that stores NULL.
+func applyRules(val string, rules []Rule) (string, bool) {
+	for _, r := range rules {
+		if r.Apply == nil {
+			continue
+		}
+		var null bool
+		if val, null = r.Apply(val); null {
+			return "", true
+		}
+	}
+	return val, false
+}
+
+// applyRuleSchema marks the columns of Nullable rules nullable and those
+// of Trim rules trimmed.
+func applyRuleSchema(tables map[string]*tableSchema, rules []Rule) {
+	for _, r := range rules {
+		if ts, ok := tables[r.Table]; ok {
+			for i := range ts.columns {
+				if ts.columns[i].name == r.Column {
+					ts.columns[i].nullable = ts.columns[i].nullable || r.Nullable
+					ts.columns[i].trim = ts.columns[i].trim || r.Trim
+				}
+			}
+		}
+	}
+}
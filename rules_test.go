@@ -0,0 +1,96 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRules(t *testing.T) {
+	db := openTestDB(t)
+	for _, r := range DefaultRules() {
+		if r.Nullable {
+			var notNull int
+			err := db.QueryRow(fmt.Sprintf(`SELECT "notnull" FROM pragma_table_info(%q) WHERE name = ?`, r.Table), r.Column).Scan(&notNull)
+			if err != nil {
+				t.Fatalf("%s.%s: %v", r.Table, r.Column, err)
+			}
+			if notNull != 0 {
+				t.Errorf("%s.%s is NOT NULL; its rule stores NULL", r.Table, r.Column)
+			}
+		}
+
+		// Every stored value is already normalized.
+		rows, err := db.Query(fmt.Sprintf(`SELECT DISTINCT %q FROM %q WHERE %q IS NOT NULL`, r.Column, r.Table, r.Column))
+		if err != nil {
+			t.Fatalf("%s.%s: %v", r.Table, r.Column, err)
+		}
+		for rows.Next() {
+			var v string
+			if err := rows.Scan(&v); err != nil {
+				t.Fatal(err)
+			}
+			if r.Trim && strings.TrimSpace(v) != v {
+				t.Errorf("%s.%s has untrimmed %q", r.Table, r.Column, v)
+			}
+			if r.Apply == nil {
+				continue
+			}
+			if out, null := r.Apply(v); null || out != v {
+				t.Errorf("%s.%s has %q, which its rule changes", r.Table, r.Column, v)
+			}
+		}
+		rows.Close()
+	}
+}
+
+func TestWithRules(t *testing.T) {
+	schema := &tableSchema{name: "T", columns: []columnDef{
+		{name: "TYPE", dataType: "TEXT"},
+		{name: "NAME", dataType: "TEXT"},
+	}}
+	csvData := "TYPE,NAME\nWP   ,  Boise \nRP,NONE\n"
+	cfg := newConfig([]Option{WithRules(
+		TrimRule("T", "TYPE"),
+		TrimRule("T", "NAME"),
+		UpperRule("T", "NAME"),
+		SentinelNullRule("T", "NAME", "NONE"),
+		FuncRule("T", "TYPE", func(s string) string { return s + "!" }),
+	)})
+	tables := map[string]*tableSchema{"T": schema}
+	applyRuleSchema(tables, cfg.rules)
+	if !schema.columns[1].nullable || schema.columns[0].nullable {
+		t.Errorf("nullable = %v, %v; want false, true", schema.columns[0].nullable, schema.columns[1].nullable)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	createTables, _ := generateDDL(tables, nil)
+	if _, err := db.Exec(createTables[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadCSV(db, strings.NewReader(csvData), "T.csv", schema, cfg); err != nil {
+		t.Fatalf("loadCSV: %v", err)
+	}
+	var got []string
+	rows, err := db.Query(`SELECT TYPE, coalesce(NAME, 'NULL') FROM T ORDER BY rowid`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var typ, name string
+		if err := rows.Scan(&typ, &name); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, typ+"|"+name)
+	}
+	if strings.Join(got, ",") != "WP!|BOISE,RP!|NULL" {
+		t.Errorf("rows = %v", got)
+	}
+}
@@ -0,0 +1,468 @@
+package nasr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnparsedSchedule is returned by Schedule.IsOpenAt when the schedule
+// text was not understood. The text is still available in Schedule.Raw.
+var ErrUnparsedSchedule = errors.New("nasr: schedule not understood")
+
+// Schedule is a structured form of a free-text hours column such as
+// APT_ATT's MONTH/DAY/HOUR, ATC_BASE.TWR_HRS, ATC_ATIS.ATIS_HRS,
+// COM.OPR_HRS, CLS_ARSP.AIRSPACE_HRS, RDR.RADAR_HRS or a TIME_OF_USE.
+type Schedule struct {
+	Raw string // the text as published
+
+	// Parsed reports whether Raw was understood. When it is false Periods
+	// is empty and IsOpenAt returns ErrUnparsedSchedule.
+	Parsed bool
+
+	// Periods are the times the facility is open; a parsed schedule with
+	// no periods (e.g. "UNATNDD") is never open.
+	Periods []SchedulePeriod
+
+	// Position is used to work out sunrise and sunset for periods that
+	// use them. Airport.AttendanceSchedule sets it; otherwise set it
+	// before calling IsOpenAt.
+	Position *LatLon
+}
+
+// SchedulePeriod is one set of months, days and hours.
+type SchedulePeriod struct {
+	// FromMonth and ToMonth are an inclusive range that may wrap the year
+	// end (e.g. NOV-MAR). Zero means all year.
+	FromMonth, ToMonth time.Month
+
+	// Days are the days of the week; zero means every day.
+	Days Weekdays
+
+	// Start and End are the opening hours; End before Start runs past
+	// midnight. AllDay periods ignore them.
+	Start, End ClockTime
+	AllDay     bool
+
+	// UTC reports that the hours are Zulu rather than local time.
+	UTC bool
+
+	// DSTShift reports the FAA "++" suffix: the UTC hours are one hour
+	// earlier while daylight saving time is in effect.
+	DSTShift bool
+}
+
+// Weekdays is a set of days of the week, one bit per time.Weekday.
+type Weekdays uint8
+
+// Has reports whether d is in the set.
+func (w Weekdays) Has(d time.Weekday) bool { return w&(1<<d) != 0 }
+
+func weekdayRange(from, to time.Weekday) Weekdays {
+	var w Weekdays
+	for d := from; ; d = (d + 1) % 7 {
+		w |= 1 << d
+		if d == to {
+			return w
+		}
+	}
+}
+
+// ClockTime is a time of day in minutes after midnight, or Sunrise or
+// Sunset.
+type ClockTime int
+
+const (
+	Sunrise ClockTime = -1
+	Sunset  ClockTime = -2
+)
+
+func (c ClockTime) String() string {
+	switch c {
+	case Sunrise:
+		return "SR"
+	case Sunset:
+		return "SS"
+	}
+	return fmt.Sprintf("%02d%02d", c/60, c%60)
+}
+
+// ParseSchedule parses an FAA hours value. It understands the common
+// patterns: clock ranges such as "0600-2200", "0600-2200 LCL" and
+// "1200-0400Z++"; "24" and "CONTINUOUS"; "SR-SS", "SUNRISE-SUNSET", "DALGT"
+// and "DAWN-DUSK"; day lists such as "MON-FRI", "SAT", "WKDAYS" and
+// "WEEKENDS"; month ranges such as "MAY-NOV"; "ALL"; and "UNATNDD".
+// Several periods may be separated by ";" or "," or simply follow one
+// another ("SUN-FRI 0530-0030 SAT 0530-0000"); days separated by commas
+// share their hours ("MON,WED,FRI 0800-1700" or "0800-1700 MON, WED"). The
+// "CLASS D SVC" prefix and "OTHER TIMES CLASS E" clause of AIRSPACE_HRS are
+// skipped. Anything else, such as holiday exceptions or NOTAM references,
+// leaves the schedule unparsed with the text kept in Raw.
+func ParseSchedule(s string) Schedule {
+	sched := Schedule{Raw: s}
+	norm := strings.ToUpper(strings.TrimSpace(s))
+	norm = scheduleRangeSep.ReplaceAllString(norm, "-")
+	// CLS_ARSP.AIRSPACE_HRS: "CLASS D SVC 0700-2300; OTHER TIMES CLASS G".
+	norm = scheduleAirspace.ReplaceAllString(norm, "")
+	var periods []SchedulePeriod
+	for _, clause := range strings.FieldsFunc(norm, func(r rune) bool { return r == ';' }) {
+		ps, ok := parseScheduleParts(clause)
+		if !ok {
+			return sched
+		}
+		periods = append(periods, ps...)
+	}
+	if norm == "" {
+		return sched
+	}
+	sched.Parsed, sched.Periods = true, periods
+	return sched
+}
+
+var (
+	scheduleRangeSep   = regexp.MustCompile(`\s*-\s*|\s+(?:TO|THRU|THROUGH)\s+`)
+	scheduleAirspace   = regexp.MustCompile(`^CLASS [A-E] SVC\s+`)
+	scheduleOtherTimes = regexp.MustCompile(`^\s*OTHER TIMES CLASS [A-G]\.?\s*$`)
+	scheduleClock      = regexp.MustCompile(`^([0-9]{4}|SR|SS|SUNRISE|SUNSET|DAWN|DUSK)-([0-9]{4}|SR|SS|SUNRISE|SUNSET|DAWN|DUSK)(Z|Z\+\+)?$`)
+)
+
+// parseScheduleParts parses a ";" clause. Its comma-separated parts are
+// periods, or bare day lists that share the hours of the next part or, at
+// the end, of the previous one. A day list with no part to join, such as
+// "SAT, SUN", is open all day; one whose neighbour applies to every day, as
+// in "0800-1700, SAT", leaves the schedule unparsed.
+func parseScheduleParts(clause string) ([]SchedulePeriod, bool) {
+	var out []SchedulePeriod
+	var pending Weekdays
+	for _, part := range strings.Split(clause, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 || scheduleOtherTimes.MatchString(part) {
+			continue
+		}
+		if days, ok := parseDayList(words); ok {
+			pending |= days
+			continue
+		}
+		ps, ok := parseScheduleClause(words)
+		if !ok {
+			return nil, false
+		}
+		if pending != 0 {
+			if len(ps) == 0 || ps[0].Days == 0 {
+				return nil, false
+			}
+			ps[0].Days |= pending
+			pending = 0
+		}
+		out = append(out, ps...)
+	}
+	if pending != 0 {
+		if len(out) == 0 {
+			return []SchedulePeriod{{Days: pending, AllDay: true}}, true
+		}
+		last := &out[len(out)-1]
+		if last.Days == 0 {
+			return nil, false
+		}
+		last.Days |= pending
+	}
+	return out, true
+}
+
+// parseDayList parses words that are only days of the week, e.g. "MON" or
+// "SAT-SUN", and returns their union.
+func parseDayList(words []string) (Weekdays, bool) {
+	var days Weekdays
+	for _, w := range words {
+		w = strings.TrimRight(w, ".")
+		switch w {
+		case "WKDAYS", "WEEKDAYS":
+			days |= weekdayRange(time.Monday, time.Friday)
+			continue
+		case "WKEND", "WKENDS", "WEEKEND", "WEEKENDS":
+			days |= weekdayRange(time.Saturday, time.Sunday)
+			continue
+		}
+		from, to, isRange := strings.Cut(w, "-")
+		if !isRange {
+			to = from
+		}
+		d1, ok1 := parseWeekday(from)
+		d2, ok2 := parseWeekday(to)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		days |= weekdayRange(d1, d2)
+	}
+	return days, true
+}
+
+// parseScheduleClause parses the words of one clause. A new period starts
+// whenever a word fills a part of the current period that is already set,
+// so "SUN-FRI 0530-0030 SAT 0530-0000" is two periods.
+func parseScheduleClause(words []string) ([]SchedulePeriod, bool) {
+	var out []SchedulePeriod
+	var cur SchedulePeriod
+	var hasMonths, hasDays, hasTime, closed, seen bool
+	flush := func() {
+		if seen && !closed {
+			if !hasTime {
+				cur.AllDay = true
+			}
+			out = append(out, cur)
+		}
+		cur = SchedulePeriod{}
+		hasMonths, hasDays, hasTime, closed, seen = false, false, false, false, false
+	}
+	setTime := func(start, end ClockTime, allDay bool) {
+		if hasTime {
+			flush()
+		}
+		cur.Start, cur.End, cur.AllDay = start, end, allDay
+		hasTime, seen = true, true
+	}
+
+	for _, w := range words {
+		w = strings.TrimRight(w, ".")
+		switch w {
+		case "", "HRS", "HOURS", "ONLY", "LCL", "LOCAL":
+			continue
+		case "ALL", "DAILY", "DLY":
+			seen = true
+			continue
+		case "24", "H24", "24HRS", "CONTINUOUS", "CONT":
+			setTime(0, 0, true)
+			continue
+		case "DALGT", "DAYLIGHT":
+			setTime(Sunrise, Sunset, false)
+			continue
+		case "Z", "UTC", "Z++":
+			cur.UTC, cur.DSTShift = true, w == "Z++"
+			continue
+		case "UNATNDD", "UNATTENDED":
+			closed, seen = true, true
+			continue
+		case "WKDAYS", "WEEKDAYS":
+			if hasDays {
+				flush()
+			}
+			cur.Days, hasDays, seen = weekdayRange(time.Monday, time.Friday), true, true
+			continue
+		case "WKEND", "WKENDS", "WEEKEND", "WEEKENDS":
+			if hasDays {
+				flush()
+			}
+			cur.Days, hasDays, seen = weekdayRange(time.Saturday, time.Sunday), true, true
+			continue
+		}
+
+		if m := scheduleClock.FindStringSubmatch(w); m != nil {
+			start, ok1 := parseClockTime(m[1])
+			end, ok2 := parseClockTime(m[2])
+			if !ok1 || !ok2 {
+				return nil, false
+			}
+			setTime(start, end, false)
+			cur.UTC, cur.DSTShift = m[3] != "", m[3] == "Z++"
+			continue
+		}
+
+		from, to, isRange := strings.Cut(w, "-")
+		if !isRange {
+			to = from
+		}
+		if d1, ok := parseWeekday(from); ok {
+			d2, ok := parseWeekday(to)
+			if !ok {
+				return nil, false
+			}
+			if hasDays {
+				flush()
+			}
+			cur.Days, hasDays, seen = weekdayRange(d1, d2), true, true
+			continue
+		}
+		if m1, ok := parseMonth(from); ok {
+			m2, ok := parseMonth(to)
+			if !ok {
+				return nil, false
+			}
+			if hasMonths {
+				flush()
+			}
+			cur.FromMonth, cur.ToMonth, hasMonths, seen = m1, m2, true, true
+			continue
+		}
+		return nil, false
+	}
+	flush()
+	return out, true
+}
+
+func parseClockTime(s string) (ClockTime, bool) {
+	switch s {
+	case "SR", "SUNRISE", "DAWN":
+		return Sunrise, true
+	case "SS", "SUNSET", "DUSK":
+		return Sunset, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n/100 > 24 || n%100 > 59 || (n/100 == 24 && n%100 != 0) {
+		return 0, false
+	}
+	return ClockTime(n/100*60 + n%100), true
+}
+
+// parseWeekday accepts a day name or any abbreviation of at least three
+// letters, e.g. "THU", "THUR" or "THURS".
+func parseWeekday(s string) (time.Weekday, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.HasPrefix(strings.ToUpper(d.String()), s) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseMonth accepts a month name or any abbreviation of at least three
+// letters, e.g. "SEP" or "SEPT".
+func parseMonth(s string) (time.Month, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToUpper(m.String()), s) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// IsOpenAt reports whether the schedule is open at t. Local hours are
+// interpreted in loc, the facility's time zone, which is also used to decide
+// whether daylight saving time is in effect for "++" hours. Periods that
+// use sunrise or sunset need Position. The error wraps ErrUnparsedSchedule
+// when the text was not understood.
+func (s *Schedule) IsOpenAt(t time.Time, loc *time.Location) (bool, error) {
+	if !s.Parsed {
+		return false, fmt.Errorf("%q: %w", s.Raw, ErrUnparsedSchedule)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, p := range s.Periods {
+		open, err := p.openAt(t, loc, s.Position)
+		if err != nil {
+			return false, fmt.Errorf("%q: %w", s.Raw, err)
+		}
+		if open {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// String returns the schedule as published.
+func (s Schedule) String() string { return s.Raw }
+
+func (p *SchedulePeriod) matchesDate(d time.Time) bool {
+	if p.Days != 0 && !p.Days.Has(d.Weekday()) {
+		return false
+	}
+	if p.FromMonth == 0 {
+		return true
+	}
+	m := d.Month()
+	if p.FromMonth <= p.ToMonth {
+		return m >= p.FromMonth && m <= p.ToMonth
+	}
+	return m >= p.FromMonth || m <= p.ToMonth
+}
+
+// openAt checks the period's window on the day of t and on the day before,
+// for hours that run past midnight. Days and months are those of the zone
+// the hours are given in.
+func (p *SchedulePeriod) openAt(t time.Time, loc *time.Location, pos *LatLon) (bool, error) {
+	zone := loc
+	if p.UTC {
+		zone = time.UTC
+	}
+	local := t.In(zone)
+	if p.AllDay {
+		return p.matchesDate(local), nil
+	}
+	for back := 0; back <= 1; back++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()-back, 0, 0, 0, 0, zone)
+		if !p.matchesDate(day) {
+			continue
+		}
+		start, err := p.Start.on(day, pos)
+		if err != nil {
+			return false, err
+		}
+		end, err := p.End.on(day, pos)
+		if err != nil {
+			return false, err
+		}
+		if !end.After(start) {
+			if end, err = p.End.on(day.AddDate(0, 0, 1), pos); err != nil {
+				return false, err
+			}
+		}
+		if p.DSTShift && t.In(loc).IsDST() {
+			start, end = start.Add(-time.Hour), end.Add(-time.Hour)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// on returns the clock time on the given day, in the zone the hours are
+// given in. Clock times are wall-clock times, so on the days daylight saving
+// time starts or ends they are not a fixed duration after midnight.
+func (c ClockTime) on(day time.Time, pos *LatLon) (time.Time, error) {
+	if c >= 0 {
+		return time.Date(day.Year(), day.Month(), day.Day(), int(c)/60, int(c)%60, 0, 0, day.Location()), nil
+	}
+	if pos == nil {
+		return time.Time{}, errors.New("sunrise and sunset need a Position")
+	}
+	rise, set := sunTimes(day, pos.Lat, pos.Lon)
+	if c == Sunrise {
+		return rise, nil
+	}
+	return set, nil
+}
+
+// sunTimes returns sunrise and sunset for the calendar date of day at a
+// position, using the NOAA sunrise equation. During polar day the sun
+// "rises" twelve hours before solar noon and sets twelve hours after; during
+// polar night both are solar noon.
+func sunTimes(day time.Time, lat, lon float64) (rise, set time.Time) {
+	const j2000 = 2451545.0
+	date := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(date.Unix())/86400 + 2440587.5 - j2000)
+	jStar := n - lon/360
+	m := math.Mod(357.5291+0.98560028*jStar, 360)
+	mr := deg2rad(m)
+	c := 1.9148*math.Sin(mr) + 0.0200*math.Sin(2*mr) + 0.0003*math.Sin(3*mr)
+	lambda := deg2rad(math.Mod(m+c+180+102.9372, 360))
+	transit := j2000 + jStar + 0.0053*math.Sin(mr) - 0.0069*math.Sin(2*lambda)
+	decl := math.Asin(math.Sin(lambda) * math.Sin(deg2rad(23.4397)))
+	phi := deg2rad(lat)
+	cosH := (math.Sin(deg2rad(-0.833)) - math.Sin(phi)*math.Sin(decl)) / (math.Cos(phi) * math.Cos(decl))
+	h := rad2deg(math.Acos(math.Max(-1, math.Min(1, cosH))))
+	toTime := func(j float64) time.Time {
+		return time.Unix(0, int64((j-2440587.5)*86400*1e9)).UTC()
+	}
+	return toTime(transit - h/360), toTime(transit + h/360)
+}
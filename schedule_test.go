@@ -0,0 +1,141 @@
+package nasr
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		in      string
+		parsed  bool
+		periods int
+	}{
+		{"24", true, 1},
+		{"0600-2200 LCL", true, 1},
+		{"1200-0400Z++", true, 1},
+		{"0530-1930 MON-FRI; 0800-1900 SAT-SUN", true, 2},
+		{"SUN-FRI 0530-0030 SAT 0530-0000", true, 2},
+		{"ALL MON - FRI 0800-1800", true, 1},
+		{"MAY-NOV ALL DALGT", true, 1},
+		{"DAILY SR-SS", true, 1},
+		{"SUNRISE TO SUNSET.", true, 1},
+		{"CLASS D SVC 0700-2300; OTHER TIMES CLASS G", true, 1},
+		{"ALL ALL UNATNDD", true, 0},
+		{"MON,WED,FRI 0800-1700", true, 1},
+		{"0800-1700 MON, WED", true, 1},
+		{"0530-1930 MON-FRI, 0800-1900 SAT-SUN", true, 2},
+		{"SAT, SUN", true, 1},
+		{"0800-1700, SAT", false, 0},
+		{"1500-0700Z++ MON-FRI EXCEPT FED HOLS.", false, 0},
+		{"SEE GENERAL REMARKS", false, 0},
+		{"2500-0100", false, 0},
+		{"", false, 0},
+	}
+	for _, tt := range tests {
+		s := ParseSchedule(tt.in)
+		if s.Parsed != tt.parsed || len(s.Periods) != tt.periods || s.Raw != tt.in {
+			t.Errorf("ParseSchedule(%q) = parsed %v, %d periods; want %v, %d", tt.in, s.Parsed, len(s.Periods), tt.parsed, tt.periods)
+		}
+	}
+
+	s := ParseSchedule("SUN-FRI 0530-0030 SAT 0530-0000")
+	if p := s.Periods[1]; p.Days != 1<<time.Saturday || p.Start != 330 || p.End != 0 {
+		t.Errorf("second period = %+v", p)
+	}
+	s = ParseSchedule("MON,WED,FRI 0800-1700")
+	if p := s.Periods[0]; p.Days != 1<<time.Monday|1<<time.Wednesday|1<<time.Friday || p.AllDay || p.Start != 480 {
+		t.Errorf("comma-separated days = %+v", p)
+	}
+}
+
+func TestSchedule_IsOpenAt(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	at := func(y int, m time.Month, d, hh, mm int) time.Time {
+		return time.Date(y, m, d, hh, mm, 0, 0, denver)
+	}
+	// 2026-06-19 is a Friday and 2026-06-20 a Saturday; June is daylight
+	// saving time.
+	tests := []struct {
+		sched string
+		t     time.Time
+		want  bool
+	}{
+		{"24", at(2026, 6, 20, 3, 0), true},
+		{"0600-2200", at(2026, 6, 19, 5, 59), false},
+		{"0600-2200", at(2026, 6, 19, 6, 0), true},
+		{"0600-2200", at(2026, 6, 19, 22, 0), false},
+		{"0530-1930 MON-FRI; 0800-1900 SAT-SUN", at(2026, 6, 20, 7, 0), false},
+		{"0530-1930 MON-FRI; 0800-1900 SAT-SUN", at(2026, 6, 20, 8, 30), true},
+		// Past midnight on the following day.
+		{"SUN-FRI 0530-0030 SAT 0530-0000", at(2026, 6, 20, 0, 15), true},
+		{"SUN-FRI 0530-0030 SAT 0530-0000", at(2026, 6, 21, 0, 15), false},
+		// 1300-0500Z is 0600-2200 MST; with "++" it is one hour earlier in
+		// UTC during daylight saving time, so still 0600-2200 local.
+		{"1300-0500Z++", at(2026, 6, 19, 5, 30), false},
+		{"1300-0500Z++", at(2026, 6, 19, 6, 30), true},
+		{"1300-0500Z++", at(2026, 6, 19, 22, 30), false},
+		{"1300-0500Z++", at(2026, 1, 16, 6, 30), true},
+		{"1300-0500Z++", at(2026, 1, 16, 22, 30), false},
+		{"1300-0500Z", at(2026, 6, 19, 22, 30), true},
+		{"MAY-NOV ALL 0800-1700", at(2026, 12, 1, 12, 0), false},
+		{"MAY-NOV ALL 0800-1700", at(2026, 11, 30, 12, 0), true},
+		{"ALL ALL UNATNDD", at(2026, 6, 19, 12, 0), false},
+		// 2026-06-17 is a Wednesday and 2026-06-16 a Tuesday.
+		{"MON,WED,FRI 0800-1700", at(2026, 6, 17, 7, 0), false},
+		{"MON,WED,FRI 0800-1700", at(2026, 6, 17, 12, 0), true},
+		{"MON,WED,FRI 0800-1700", at(2026, 6, 16, 12, 0), false},
+		{"0800-1700 MON, WED", at(2026, 6, 17, 7, 0), false},
+		{"0800-1700 MON, WED", at(2026, 6, 17, 12, 0), true},
+		// Daylight saving time starts at 0200 on 2026-03-08; clock times
+		// are wall-clock times on that day.
+		{"0100-0300", at(2026, 3, 8, 1, 30), true},
+		{"0100-0300", at(2026, 3, 8, 3, 30), false},
+		{"2200-0400", at(2026, 3, 8, 3, 30), true},
+		{"2200-0400", at(2026, 3, 8, 4, 30), false},
+		// Sunrise in Denver on 2026-06-21 is about 0532 MDT, sunset 2031.
+		{"SR-SS", at(2026, 6, 21, 5, 20), false},
+		{"SR-SS", at(2026, 6, 21, 5, 45), true},
+		{"SR-SS", at(2026, 6, 21, 20, 20), true},
+		{"SR-SS", at(2026, 6, 21, 20, 45), false},
+	}
+	for _, tt := range tests {
+		s := ParseSchedule(tt.sched)
+		s.Position = &LatLon{39.74, -104.99}
+		got, err := s.IsOpenAt(tt.t, denver)
+		if err != nil || got != tt.want {
+			t.Errorf("%q at %v = %v, %v; want %v", tt.sched, tt.t, got, err, tt.want)
+		}
+	}
+
+	s := ParseSchedule("SEE GENERAL REMARKS")
+	if _, err := s.IsOpenAt(at(2026, 6, 19, 12, 0), denver); !errors.Is(err, ErrUnparsedSchedule) {
+		t.Errorf("unparsed IsOpenAt error = %v, want ErrUnparsedSchedule", err)
+	}
+	s = ParseSchedule("SR-SS")
+	if _, err := s.IsOpenAt(at(2026, 6, 19, 12, 0), denver); err == nil {
+		t.Error("SR-SS without a Position: want an error")
+	}
+}
+
+func TestAirport_AttendanceSchedule(t *testing.T) {
+	a := Airport{Lat: 43.56, Lon: -116.22, Attendance: []Attendance{
+		{Seq: 1, Month: "ALL", Day: "MON-FRI", Hour: "0800-1700"},
+		{Seq: 2, Month: "ALL", Day: "SAT-SUN", Hour: "DALGT"},
+	}}
+	s := a.AttendanceSchedule()
+	if !s.Parsed || len(s.Periods) != 2 || s.Position == nil || s.Raw != "ALL MON-FRI 0800-1700; ALL SAT-SUN DALGT" {
+		t.Fatalf("AttendanceSchedule = %+v", s)
+	}
+	// Saturday noon at the airport is daylight.
+	if open, err := s.IsOpenAt(time.Date(2026, 6, 20, 18, 0, 0, 0, time.UTC), time.FixedZone("MDT", -6*3600)); err != nil || !open {
+		t.Errorf("Saturday noon = %v, %v; want open", open, err)
+	}
+	if s := (&Airport{}).AttendanceSchedule(); s.Parsed {
+		t.Errorf("no attendance entries: Parsed = true")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"log"
 	"sort"
 	"strings"
 )
@@ -14,6 +15,7 @@ type columnDef struct {
 	name     string
 	dataType string
 	nullable bool
+	trim     bool // remove padding from values; see Rule.Trim
 }
 
 type tableSchema struct {
@@ -31,87 +33,110 @@ func parseSchemas(zr *zip.Reader) (map[string]*tableSchema, error) {
 			continue
 		}
 
-		rc, err := f.Open()
+		rows, short, err := readStructure(f)
 		if err != nil {
-			return nil, fmt.Errorf("open %s: %w", f.Name, err)
+			return nil, err
+		}
+		for _, line := range short {
+			log.Printf("WARNING: %s line %d: skipping record with fewer than 5 fields", f.Name, line)
 		}
 
-		raw, err := io.ReadAll(rc)
-		rc.Close()
-		if err != nil {
-			return nil, fmt.Errorf("read %s: %w", f.Name, err)
+		for _, row := range rows {
+			ts, ok := tables[row.table]
+			if !ok {
+				ts = &tableSchema{name: row.table}
+				tables[row.table] = ts
+			}
+			ts.columns = append(ts.columns, row.column)
 		}
+	}
 
-		// Some files (notably FSS_CSV_DATA_STRUCTURE.csv) use bare CR (0x0d)
-		// line endings instead of CRLF. Go's csv.Reader does not handle bare
-		// CR as a line terminator. Replace bare \r with \n.
-		raw = normalizeCR(raw)
+	return tables, nil
+}
 
-		r := csv.NewReader(bytes.NewReader(raw))
-		r.FieldsPerRecord = -1 // allow variable field count
+// structureRow is one column definition from a _CSV_DATA_STRUCTURE.csv file.
+type structureRow struct {
+	line   int
+	table  string
+	column columnDef
+}
 
-		// Read and discard header row.
-		if _, err := r.Read(); err != nil {
-			return nil, fmt.Errorf("read header in %s: %w", f.Name, err)
-		}
+// readStructure parses a _CSV_DATA_STRUCTURE.csv file. short lists the line
+// numbers of records with fewer than the five expected fields, which are
+// skipped.
+func readStructure(f *zip.File) (rows []structureRow, short []int, err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	raw, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", f.Name, err)
+	}
 
-		for {
-			record, err := r.Read()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				return nil, fmt.Errorf("parse %s: %w", f.Name, err)
-			}
-			if len(record) < 5 {
-				continue
-			}
+	// Some files (notably FSS_CSV_DATA_STRUCTURE.csv) use bare CR (0x0d)
+	// line endings instead of CRLF. Go's csv.Reader does not handle bare
+	// CR as a line terminator. Replace bare \r with \n.
+	raw = normalizeCR(raw)
 
-			tableName := strings.TrimSpace(record[0])
-			colName := strings.TrimSpace(record[1])
-			// record[2] is Max Length (unused)
-			dataType := strings.TrimSpace(record[3])
-			nullableStr := strings.TrimSpace(record[4])
+	r := csv.NewReader(bytes.NewReader(raw))
+	r.FieldsPerRecord = -1 // allow variable field count
 
-			colName = strings.ReplaceAll(colName, " ", "_")
+	// Read and discard header row.
+	if _, err := r.Read(); err != nil {
+		return nil, nil, fmt.Errorf("read header in %s: %w", f.Name, err)
+	}
 
-			switch dataType {
-			case "VARCHAR":
-				dataType = "TEXT"
-			case "NUMBER":
-				dataType = "REAL"
-			}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse %s: %w", f.Name, err)
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) < 5 {
+			short = append(short, line)
+			continue
+		}
 
-			nullable := nullableStr == "Yes"
+		tableName := strings.TrimSpace(record[0])
+		colName := strings.TrimSpace(record[1])
+		// record[2] is Max Length (unused)
+		dataType := strings.TrimSpace(record[3])
+		nullableStr := strings.TrimSpace(record[4])
 
-			ts, ok := tables[tableName]
-			if !ok {
-				ts = &tableSchema{name: tableName}
-				tables[tableName] = ts
-			}
-			ts.columns = append(ts.columns, columnDef{
-				name:     colName,
-				dataType: dataType,
-				nullable: nullable,
-			})
-		}
-	}
+		colName = strings.ReplaceAll(colName, " ", "_")
 
-	// Override nullability for columns with sentinel NULL values.
-	// These columns are marked NOT NULL in the FAA schema but contain
-	// placeholder values (e.g., "NOT ASSIGNED") that we convert to NULL.
-	for key := range sentinelNulls {
-		tableName, colName := key[0], key[1]
-		if ts, ok := tables[tableName]; ok {
-			for i := range ts.columns {
-				if ts.columns[i].name == colName {
-					ts.columns[i].nullable = true
-				}
-			}
+		switch dataType {
+		case "VARCHAR":
+			dataType = "TEXT"
+		case "NUMBER":
+			dataType = "REAL"
 		}
+
+		rows = append(rows, structureRow{
+			line:  line,
+			table: tableName,
+			column: columnDef{
+				name:     colName,
+				dataType: dataType,
+				nullable: nullableStr == "Yes",
+			},
+		})
 	}
+	return rows, short, nil
+}
 
-	return tables, nil
+// derivedTables returns the schemas of tables that are computed from the
+// loaded data rather than read from a CSV file.
+func derivedTables(tables map[string]*tableSchema) []*tableSchema {
+	return append([]*tableSchema{
+		arbPolygonSchema,
+		nasrCycleSchema,
+	}, explodeSchemas(tables)...)
 }
 
 // normalizeCR replaces bare \r (not followed by \n) with \n.
@@ -0,0 +1,120 @@
+package nasr
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// searchTable is the FTS5 virtual table created by WithFullTextSearch.
+const searchTable = "NASR_SEARCH"
+
+// searchSource describes one indexed text column and the key of the entity
+// that owns it. For remark tables the owner is the parent table.
+type searchSource struct {
+	group      string
+	table      string
+	column     string
+	entity     string
+	keyColumns []string
+}
+
+func searchSources() []searchSource {
+	return []searchSource{
+		{"APT", "APT_BASE", "ARPT_NAME", "APT_BASE", []string{"SITE_NO"}},
+		{"APT", "APT_BASE", "CITY", "APT_BASE", []string{"SITE_NO"}},
+		{"APT", "APT_RMK", "REMARK", "APT_BASE", []string{"SITE_NO"}},
+		{"ATC", "ATC_RMK", "REMARK", "ATC_BASE", []string{"FACILITY_ID", "FACILITY_TYPE"}},
+		{"CLS_ARSP", "CLS_ARSP", "REMARK", "CLS_ARSP", []string{"SITE_NO"}},
+		{"FSS", "FSS_RMK", "REMARK", "FSS_BASE", []string{"FSS_ID"}},
+		{"HPF", "HPF_RMK", "REMARK", "HPF_BASE", []string{"HP_NAME", "HP_NO"}},
+		{"ILS", "ILS_RMK", "REMARK", "ILS_BASE", []string{"SITE_NO", "RWY_END_ID", "ILS_LOC_ID"}},
+		{"MAA", "MAA_BASE", "DESCRIPTION", "MAA_BASE", []string{"MAA_ID"}},
+		{"MAA", "MAA_RMK", "REMARK", "MAA_BASE", []string{"MAA_ID"}},
+		{"NAV", "NAV_RMK", "REMARK", "NAV_BASE", []string{"NAV_ID", "NAV_TYPE", "CITY", "COUNTRY_CODE"}},
+		{"PJA", "PJA_BASE", "DESCRIPTION", "PJA_BASE", []string{"PJA_ID"}},
+	}
+}
+
+// buildSearchIndex creates the NASR_SEARCH FTS5 table and fills it from every
+// search source whose table exists in the schema.
+func buildSearchIndex(db *sql.DB, tables map[string]*tableSchema) error {
+	stmt := fmt.Sprintf(`CREATE VIRTUAL TABLE %q USING fts5(
+  "GRP" UNINDEXED, "TBL" UNINDEXED, "COL" UNINDEXED, "ENTITY" UNINDEXED, "ENTITY_KEY" UNINDEXED, "CONTENT"
+);`, searchTable)
+	if _, err := db.Exec(stmt); err != nil {
+		return fmt.Errorf("create %s: %w", searchTable, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, src := range searchSources() {
+		if _, ok := tables[src.table]; !ok {
+			continue
+		}
+		// REAL key columns such as HPF_BASE.HP_NO hold whole numbers; key
+		// them as "1" rather than "1.0" so they match the published value.
+		keyArgs := make([]string, len(src.keyColumns))
+		for i, c := range src.keyColumns {
+			keyArgs[i] = fmt.Sprintf(`'%s', CASE WHEN typeof(%q) = 'real' AND %q = CAST(%q AS INTEGER) THEN printf('%%d', %q) ELSE CAST(%q AS TEXT) END`,
+				c, c, c, c, c, c)
+		}
+		query := fmt.Sprintf(
+			`INSERT INTO %q ("GRP", "TBL", "COL", "ENTITY", "ENTITY_KEY", "CONTENT")
+SELECT ?, ?, ?, ?, json_object(%s), %q FROM %q WHERE %q IS NOT NULL AND %q != ''`,
+			searchTable, strings.Join(keyArgs, ", "), src.column, src.table, src.column, src.column,
+		)
+		if _, err := tx.Exec(query, src.group, src.table, src.column, src.entity); err != nil {
+			return fmt.Errorf("index %s.%s: %w", src.table, src.column, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SearchHit is one match returned by Search.
+type SearchHit struct {
+	Group  string            // NASR data group, e.g. "APT"
+	Table  string            // table holding the matched text, e.g. "APT_RMK"
+	Column string            // column holding the matched text, e.g. "REMARK"
+	Entity string            // table of the owning entity, e.g. "APT_BASE"
+	Key    map[string]string // key columns of the owning entity
+	Text   string            // full matched text
+	Rank   float64           // FTS5 bm25 rank; lower is more relevant
+}
+
+// Search runs an FTS5 query against a database built with WithFullTextSearch
+// and returns hits across all groups, most relevant first. The query uses
+// SQLite FTS5 syntax, e.g. `"parachute jump"` or `ARPT* NOT CLSD`.
+func Search(db *sql.DB, query string) ([]SearchHit, error) {
+	rows, err := db.Query(fmt.Sprintf(
+		`SELECT "GRP", "TBL", "COL", "ENTITY", "ENTITY_KEY", "CONTENT", rank FROM %q WHERE %q MATCH ? ORDER BY rank`,
+		searchTable, searchTable,
+	), query)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var h SearchHit
+		var key string
+		if err := rows.Scan(&h.Group, &h.Table, &h.Column, &h.Entity, &key, &h.Text, &h.Rank); err != nil {
+			return nil, fmt.Errorf("scan search hit: %w", err)
+		}
+		if err := json.Unmarshal([]byte(key), &h.Key); err != nil {
+			return nil, fmt.Errorf("decode key for %s hit: %w", h.Table, err)
+		}
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("search iteration: %w", err)
+	}
+	return hits, nil
+}
@@ -0,0 +1,80 @@
+package nasr
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "search.sqlite3")
+	if err := Extract(testZipPath, dbPath, WithFullTextSearch()); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	hits, err := Search(db, "BOISE")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	found := false
+	for _, h := range hits {
+		if h.Table == "APT_BASE" && h.Column == "ARPT_NAME" && h.Key["SITE_NO"] == "04149." {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected APT_BASE.ARPT_NAME hit for BOI, got %+v", hits)
+	}
+
+	hits, err = Search(db, "APA")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	for _, h := range hits {
+		if h.Group != "MAA" {
+			continue
+		}
+		if h.Entity != "MAA_BASE" || h.Key["MAA_ID"] == "" {
+			t.Errorf("MAA hit has no owning key: %+v", h)
+		}
+		return
+	}
+	t.Error("expected MAA hit for APA")
+}
+
+func TestSearch_NoIndex(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Search(db, "BOISE"); err == nil {
+		t.Error("expected error searching a database built without WithFullTextSearch")
+	}
+}
+
+func TestBuildSearchIndex_WholeRealKey(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE HPF_RMK (HP_NAME TEXT, HP_NO REAL, REMARK TEXT);
+INSERT INTO HPF_RMK VALUES ('BOI VORTAC', 1, 'HOLD EAST'), ('BOI VORTAC', 2.5, 'HOLD WEST')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := buildSearchIndex(db, map[string]*tableSchema{"HPF_RMK": {name: "HPF_RMK"}}); err != nil {
+		t.Fatalf("buildSearchIndex: %v", err)
+	}
+	for query, want := range map[string]string{"EAST": "1", "WEST": "2.5"} {
+		hits, err := Search(db, query)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if len(hits) != 1 || hits[0].Key["HP_NO"] != want {
+			t.Errorf("Search(%q) = %+v, want HP_NO %s", query, hits, want)
+		}
+	}
+}
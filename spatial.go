@@ -0,0 +1,219 @@
+package nasr
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// spatialSource is a table with LAT_DECIMAL/LONG_DECIMAL columns that gets an
+// R*Tree index. ident is a SQL expression naming the located entity.
+type spatialSource struct {
+	table string
+	ident string
+}
+
+func spatialSources() []spatialSource {
+	return []spatialSource{
+		{"APT_BASE", `"ARPT_ID"`},
+		{"APT_RWY_END", `"ARPT_ID" || '/' || "RWY_END_ID"`},
+		{"ARB_BASE", `"LOCATION_ID"`},
+		{"ARB_SEG", `"LOCATION_ID"`},
+		{"AWOS", `"ASOS_AWOS_ID"`},
+		{"COM", `"COMM_LOC_ID"`},
+		{"FIX_BASE", `"FIX_ID"`},
+		{"FRQ", `"FACILITY"`},
+		{"FSS_BASE", `"FSS_ID"`},
+		{"ILS_BASE", `"ILS_LOC_ID"`},
+		{"ILS_DME", `"ILS_LOC_ID"`},
+		{"ILS_GS", `"ILS_LOC_ID"`},
+		{"ILS_MKR", `"ILS_LOC_ID"`},
+		{"MTR_PT", `"ROUTE_PT_ID"`},
+		{"NAV_BASE", `"NAV_ID"`},
+		{"PJA_BASE", `"PJA_ID"`},
+		{"WXL_BASE", `"WEA_ID"`},
+	}
+}
+
+func rtreeName(table string) string { return table + "_RTREE" }
+
+// buildSpatialIndex creates a <TABLE>_RTREE virtual table for every spatial
+// source present in the schema. Each R*Tree id is the rowid of the source row.
+func buildSpatialIndex(db *sql.DB, tables map[string]*tableSchema) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, src := range spatialSources() {
+		if _, ok := tables[src.table]; !ok {
+			continue
+		}
+		rt := rtreeName(src.table)
+		stmt := fmt.Sprintf(`CREATE VIRTUAL TABLE %q USING rtree(id, min_lat, max_lat, min_lon, max_lon);`, rt)
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("create %s: %w", rt, err)
+		}
+		stmt = fmt.Sprintf(
+			`INSERT INTO %q SELECT rowid, "LAT_DECIMAL", "LAT_DECIMAL", "LONG_DECIMAL", "LONG_DECIMAL" FROM %q
+WHERE typeof("LAT_DECIMAL") = 'real' AND typeof("LONG_DECIMAL") = 'real'`,
+			rt, src.table,
+		)
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("fill %s: %w", rt, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// BBox is a latitude/longitude bounding box in decimal degrees.
+type BBox struct {
+	MinLat, MinLon float64
+	MaxLat, MaxLon float64
+}
+
+// Location is a located row returned by Within and Nearest.
+type Location struct {
+	Kind       string // source table, e.g. "NAV_BASE"
+	RowID      int64  // rowid in the source table
+	Ident      string // identifier, e.g. NAV_ID or ARPT_ID/RWY_END_ID
+	Lat, Lon   float64
+	DistanceNM float64 // great-circle distance from the reference point (Nearest only)
+}
+
+// Within returns all located rows inside bbox from a database built with
+// WithSpatialIndex. Kinds restricts the search to the given source tables;
+// with no kinds every indexed table is searched.
+func Within(db *sql.DB, bbox BBox, kinds ...string) ([]Location, error) {
+	srcs, err := selectSpatialSources(kinds)
+	if err != nil {
+		return nil, err
+	}
+	var locs []Location
+	for _, src := range srcs {
+		found, err := queryRTree(db, src, bbox)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, found...)
+	}
+	return locs, nil
+}
+
+// Nearest returns up to n located rows closest to (lat, lon) by great-circle
+// distance, nearest first. Kinds restricts the search as for Within.
+func Nearest(db *sql.DB, lat, lon float64, n int, kinds ...string) ([]Location, error) {
+	srcs, err := selectSpatialSources(kinds)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	// Search boxes of doubling radius until n rows lie within the radius, so
+	// no closer row can be hiding in the corners of a larger box.
+	for radius := 10.0; ; radius *= 2 {
+		var locs []Location
+		for _, bbox := range bboxAround(lat, lon, radius) {
+			for _, src := range srcs {
+				found, err := queryRTree(db, src, bbox)
+				if err != nil {
+					return nil, err
+				}
+				locs = append(locs, found...)
+			}
+		}
+
+		var inside []Location
+		for _, l := range locs {
+			l.DistanceNM = DistanceNM(lat, lon, l.Lat, l.Lon)
+			if l.DistanceNM <= radius {
+				inside = append(inside, l)
+			}
+		}
+		if len(inside) >= n || radius >= math.Pi*earthRadiusNM {
+			sort.Slice(inside, func(i, j int) bool { return inside[i].DistanceNM < inside[j].DistanceNM })
+			if len(inside) > n {
+				inside = inside[:n]
+			}
+			return inside, nil
+		}
+	}
+}
+
+func selectSpatialSources(kinds []string) ([]spatialSource, error) {
+	all := spatialSources()
+	if len(kinds) == 0 {
+		return all, nil
+	}
+	var out []spatialSource
+	for _, k := range kinds {
+		found := false
+		for _, src := range all {
+			if src.table == k {
+				out = append(out, src)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no spatial index for %s", k)
+		}
+	}
+	return out, nil
+}
+
+func queryRTree(db *sql.DB, src spatialSource, bbox BBox) ([]Location, error) {
+	query := fmt.Sprintf(
+		`SELECT t.rowid, %s, t."LAT_DECIMAL", t."LONG_DECIMAL" FROM %q r JOIN %q t ON t.rowid = r.id
+WHERE r.max_lat >= ? AND r.min_lat <= ? AND r.max_lon >= ? AND r.min_lon <= ?`,
+		"t."+src.ident, rtreeName(src.table), src.table,
+	)
+	rows, err := db.Query(query, bbox.MinLat, bbox.MaxLat, bbox.MinLon, bbox.MaxLon)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", rtreeName(src.table), err)
+	}
+	defer rows.Close()
+
+	var locs []Location
+	for rows.Next() {
+		l := Location{Kind: src.table}
+		var ident sql.NullString
+		if err := rows.Scan(&l.RowID, &ident, &l.Lat, &l.Lon); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", rtreeName(src.table), err)
+		}
+		l.Ident = ident.String
+		// The R*Tree stores 32-bit floats, so re-check against exact values.
+		if l.Lat < bbox.MinLat || l.Lat > bbox.MaxLat || l.Lon < bbox.MinLon || l.Lon > bbox.MaxLon {
+			continue
+		}
+		locs = append(locs, l)
+	}
+	return locs, rows.Err()
+}
+
+// bboxAround returns one or two boxes covering a circle of radiusNM around a
+// point, split at the antimeridian when necessary.
+func bboxAround(lat, lon, radiusNM float64) []BBox {
+	dLat := radiusNM / 60
+	minLat, maxLat := lat-dLat, lat+dLat
+	if minLat <= -90 || maxLat >= 90 {
+		return []BBox{{math.Max(minLat, -90), -180, math.Min(maxLat, 90), 180}}
+	}
+	dLon := radiusNM / (60 * math.Cos(deg2rad(math.Max(math.Abs(minLat), math.Abs(maxLat)))))
+	if dLon >= 180 {
+		return []BBox{{minLat, -180, maxLat, 180}}
+	}
+	minLon, maxLon := lon-dLon, lon+dLon
+	switch {
+	case minLon < -180:
+		return []BBox{{minLat, minLon + 360, maxLat, 180}, {minLat, -180, maxLat, maxLon}}
+	case maxLon > 180:
+		return []BBox{{minLat, minLon, maxLat, 180}, {minLat, -180, maxLat, maxLon - 360}}
+	}
+	return []BBox{{minLat, minLon, maxLat, maxLon}}
+}
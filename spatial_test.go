@@ -0,0 +1,76 @@
+package nasr
+
+import (
+	"database/sql"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestDistanceNM(t *testing.T) {
+	// One degree of latitude is 60 nm.
+	if d := DistanceNM(43, -116, 44, -116); math.Abs(d-60.04) > 0.1 {
+		t.Errorf("DistanceNM over 1 deg lat = %f, want ~60", d)
+	}
+	if d := DistanceNM(0, 179.5, 0, -179.5); math.Abs(d-60.04) > 0.1 {
+		t.Errorf("DistanceNM across antimeridian = %f, want ~60", d)
+	}
+}
+
+func TestSpatialIndex(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "spatial.sqlite3")
+	if err := Extract(testZipPath, dbPath, WithSpatialIndex()); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	locs, err := Within(db, BBox{MinLat: 43.5, MinLon: -116.3, MaxLat: 43.6, MaxLon: -116.1}, "APT_BASE")
+	if err != nil {
+		t.Fatalf("Within: %v", err)
+	}
+	found := false
+	for _, l := range locs {
+		if l.Ident == "BOI" {
+			found = true
+		}
+		if l.Kind != "APT_BASE" {
+			t.Errorf("unexpected kind %q", l.Kind)
+		}
+	}
+	if !found {
+		t.Errorf("expected BOI within bbox, got %+v", locs)
+	}
+
+	near, err := Nearest(db, 43.5644, -116.2228, 3, "APT_BASE", "NAV_BASE")
+	if err != nil {
+		t.Fatalf("Nearest: %v", err)
+	}
+	if len(near) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(near))
+	}
+	if near[0].Ident != "BOI" || near[0].DistanceNM > 1 {
+		t.Errorf("nearest = %+v, want BOI", near[0])
+	}
+	for i := 1; i < len(near); i++ {
+		if near[i].DistanceNM < near[i-1].DistanceNM {
+			t.Errorf("results not sorted by distance: %+v", near)
+		}
+	}
+
+	// ARTCC and FIR reference points, e.g. Mauritius FIR.
+	near, err = Nearest(db, -20.4, 57.7, 1, "ARB_BASE")
+	if err != nil {
+		t.Fatalf("Nearest ARB_BASE: %v", err)
+	}
+	if len(near) != 1 || near[0].Ident != "FIMM" {
+		t.Errorf("nearest ARB_BASE = %+v, want FIMM", near)
+	}
+
+	if _, err := Within(db, BBox{}, "CDR"); err == nil {
+		t.Error("expected error for unindexed kind")
+	}
+}
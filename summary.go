@@ -0,0 +1,464 @@
+package nasr
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SummaryItem is one readable change, such as "Runway 18/36 at 0J0 length
+// changed 5000 → 5200 ft", with the airport, state and ARTCC it concerns
+// where known.
+type SummaryItem struct {
+	Kind    string `json:"kind"` // "airport", "runway", "navaid", "ils", "airway" or "fix"
+	Text    string `json:"text"`
+	Airport string `json:"airport,omitempty"` // ARPT_ID
+	State   string `json:"state,omitempty"`   // STATE_CODE
+	ARTCC   string `json:"artcc,omitempty"`
+}
+
+// Summary is the result of Summarize.
+type Summary struct {
+	OldCycle string        `json:"old_cycle,omitempty"`
+	NewCycle string        `json:"new_cycle,omitempty"`
+	Items    []SummaryItem `json:"items"`
+}
+
+// GroupBy selects how a summary is grouped for output.
+type GroupBy string
+
+const (
+	GroupByAirport GroupBy = "airport"
+	GroupByState   GroupBy = "state"
+	GroupByARTCC   GroupBy = "artcc"
+)
+
+// SummaryGroup is the items sharing an airport, state or ARTCC. Items with
+// none are in a group named "Other".
+type SummaryGroup struct {
+	Name  string        `json:"name"`
+	Items []SummaryItem `json:"items"`
+}
+
+// changeDetector turns a changed row of one table into summary items. It
+// returns nothing for changes that do not matter to an operational reader.
+type changeDetector struct {
+	kind   string
+	detect func(c Change, s *summarizer) []string
+}
+
+// changeDetectors lists the tables summarized and their detectors.
+var changeDetectors = map[string]changeDetector{
+	"APT_BASE":    {"airport", detectAirport},
+	"APT_RWY":     {"runway", detectRunway},
+	"APT_RWY_END": {"runway", detectRunwayEnd},
+	"NAV_BASE":    {"navaid", detectNavaid},
+	"ILS_BASE":    {"ils", detectILS},
+	"AWY_BASE":    {"airway", detectAirway},
+	"AWY_SEG_ALT": {"airway", detectAirwaySegment},
+	"FIX_BASE":    {"fix", detectFix},
+}
+
+// summarizer holds what the detectors need beyond a single change.
+type summarizer struct {
+	oldDB, newDB *sql.DB
+	artccs       map[string]string // SITE_NO to RESP_ARTCC_ID, loaded on first use
+	renamedFixes map[string]bool   // FIX_ID_OLD of added fixes
+}
+
+// Summarize turns a change set from Diff into readable items for the
+// changes that matter operationally: airports opened, closed or renamed,
+// runway dimensions, declared distances and lighting, navaids commissioned
+// or decommissioned, ILS systems, airway MEAs and fixes added, removed or
+// renamed. oldDB and newDB are the databases that were compared; they are
+// used to find the ARTCC of runways and ILS systems.
+func Summarize(oldDB, newDB *sql.DB, cs *ChangeSet) (*Summary, error) {
+	s := &summarizer{oldDB: oldDB, newDB: newDB, renamedFixes: map[string]bool{}}
+	for _, c := range cs.Changes {
+		if c.Table == "FIX_BASE" && c.Type == ChangeAdded {
+			if old := asString(c.Row["FIX_ID_OLD"]); old != "" {
+				s.renamedFixes[old] = true
+			}
+		}
+	}
+
+	sum := &Summary{OldCycle: cs.OldCycle, NewCycle: cs.NewCycle}
+	for _, c := range cs.Changes {
+		d, ok := changeDetectors[c.Table]
+		if !ok {
+			continue
+		}
+		texts := d.detect(c, s)
+		if len(texts) == 0 {
+			continue
+		}
+		airport, state, artcc, err := s.locate(c.Row)
+		if err != nil {
+			return nil, err
+		}
+		for _, text := range texts {
+			sum.Items = append(sum.Items, SummaryItem{Kind: d.kind, Text: text, Airport: airport, State: state, ARTCC: artcc})
+		}
+	}
+	return sum, nil
+}
+
+// locate returns the airport, state and ARTCC of a row from its own columns,
+// looking up the airport's ARTCC by SITE_NO when the row has none.
+func (s *summarizer) locate(r map[string]interface{}) (airport, state, artcc string, err error) {
+	airport = asString(r["ARPT_ID"])
+	state = asString(r["STATE_CODE"])
+	for _, col := range []string{"RESP_ARTCC_ID", "LOW_ALT_ARTCC_ID", "ARTCC_ID_LOW", "ARTCC"} {
+		if artcc = asString(r[col]); artcc != "" {
+			return airport, state, artcc, nil
+		}
+	}
+	site := asString(r["SITE_NO"])
+	if site == "" {
+		return airport, state, "", nil
+	}
+	if s.artccs == nil {
+		s.artccs = map[string]string{}
+		// Newer values win: load the old database first.
+		for _, db := range []*sql.DB{s.oldDB, s.newDB} {
+			rows, err := db.Query(`SELECT SITE_NO, RESP_ARTCC_ID FROM APT_BASE WHERE RESP_ARTCC_ID IS NOT NULL`)
+			if err != nil {
+				return "", "", "", fmt.Errorf("airport ARTCCs: %w", err)
+			}
+			for rows.Next() {
+				var site, id string
+				if err := rows.Scan(&site, &id); err != nil {
+					rows.Close()
+					return "", "", "", fmt.Errorf("airport ARTCCs: %w", err)
+				}
+				s.artccs[site] = id
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return "", "", "", fmt.Errorf("airport ARTCCs: %w", err)
+			}
+		}
+	}
+	return airport, state, s.artccs[site], nil
+}
+
+// changed returns the change to a column of a modified row.
+func changed(c Change, column string) (ColumnChange, bool) {
+	for _, cc := range c.Columns {
+		if cc.Column == column {
+			return cc, true
+		}
+	}
+	return ColumnChange{}, false
+}
+
+// arrow formats a column change as "5000 → 5200", with NULL shown as
+// "none".
+func arrow(cc ColumnChange) string {
+	show := func(v interface{}) string {
+		if s := formatValue(v); v != nil && s != "" {
+			return s
+		}
+		return "none"
+	}
+	return show(cc.Old) + " → " + show(cc.New)
+}
+
+// summaryNumber parses a numeric column stored as REAL or TEXT.
+func summaryNumber(v interface{}) (float64, bool) {
+	if f, ok := numericValue(v); ok {
+		return f, true
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(asString(v)), 64)
+	return f, err == nil
+}
+
+// columnTexts describes the changes to the listed columns of a modified
+// row as "<subject> <label> changed <old> → <new><unit>".
+func columnTexts(c Change, subject string, labels [][3]string) []string {
+	var out []string
+	for _, l := range labels {
+		if cc, ok := changed(c, l[0]); ok {
+			out = append(out, fmt.Sprintf("%s %s changed %s%s", subject, l[1], arrow(cc), l[2]))
+		}
+	}
+	return out
+}
+
+var airportStatus = map[string]string{
+	"O":  "operational",
+	"CI": "closed indefinitely",
+	"CP": "closed permanently",
+}
+
+func detectAirport(c Change, _ *summarizer) []string {
+	r := c.Row
+	name := fmt.Sprintf("%s (%s)", asString(r["ARPT_ID"]), asString(r["ARPT_NAME"]))
+	switch c.Type {
+	case ChangeAdded:
+		return []string{fmt.Sprintf("New airport %s, %s", name, asString(r["CITY"]))}
+	case ChangeRemoved:
+		return []string{fmt.Sprintf("Airport %s removed", name)}
+	}
+	subject := "Airport " + asString(r["ARPT_ID"])
+	var out []string
+	if cc, ok := changed(c, "ARPT_STATUS"); ok {
+		status := airportStatus[asString(cc.New)]
+		if status == "" {
+			status = "status " + asString(cc.New)
+		}
+		if status == "operational" {
+			status = "reopened"
+		}
+		out = append(out, fmt.Sprintf("%s %s", subject, status))
+	}
+	if cc, ok := changed(c, "ARPT_NAME"); ok {
+		out = append(out, fmt.Sprintf("%s renamed %s", subject, arrow(cc)))
+	}
+	return append(out, columnTexts(c, subject, [][3]string{
+		{"ELEV", "elevation", " ft"},
+		{"TWR_TYPE_CODE", "tower type", ""},
+		{"ICAO_ID", "ICAO identifier", ""},
+	})...)
+}
+
+func detectRunway(c Change, _ *summarizer) []string {
+	r := c.Row
+	subject := fmt.Sprintf("Runway %s at %s", asString(r["RWY_ID"]), asString(r["ARPT_ID"]))
+	switch c.Type {
+	case ChangeAdded:
+		return []string{fmt.Sprintf("New runway %s at %s, %s x %s ft",
+			asString(r["RWY_ID"]), asString(r["ARPT_ID"]), formatValue(r["RWY_LEN"]), formatValue(r["RWY_WIDTH"]))}
+	case ChangeRemoved:
+		return []string{subject + " removed"}
+	}
+	return columnTexts(c, subject, [][3]string{
+		{"RWY_LEN", "length", " ft"},
+		{"RWY_WIDTH", "width", " ft"},
+		{"SURFACE_TYPE_CODE", "surface", ""},
+		{"RWY_LGT_CODE", "edge lighting", ""},
+	})
+}
+
+func detectRunwayEnd(c Change, _ *summarizer) []string {
+	if c.Type != ChangeModified {
+		return nil // reported with the runway
+	}
+	r := c.Row
+	subject := fmt.Sprintf("Runway %s at %s", asString(r["RWY_END_ID"]), asString(r["ARPT_ID"]))
+	return columnTexts(c, subject, [][3]string{
+		{"TKOF_RUN_AVBL", "TORA", " ft"},
+		{"TKOF_DIST_AVBL", "TODA", " ft"},
+		{"ACLT_STOP_DIST_AVBL", "ASDA", " ft"},
+		{"LNDG_DIST_AVBL", "LDA", " ft"},
+		{"DISPLACED_THR_LEN", "displaced threshold", " ft"},
+		{"APCH_LGT_SYSTEM_CODE", "approach lighting", ""},
+		{"VGSI_CODE", "visual glide slope indicator", ""},
+		{"ILS_TYPE", "instrument approach type", ""},
+	})
+}
+
+// navaidDecommissioned reports whether a NAV_STATUS value means the navaid
+// is out of service for good.
+func navaidDecommissioned(status string) bool {
+	return strings.Contains(status, "DECOMMISSION") || strings.Contains(status, "SHUTDOWN")
+}
+
+func detectNavaid(c Change, _ *summarizer) []string {
+	r := c.Row
+	subject := fmt.Sprintf("%s %s", asString(r["NAV_TYPE"]), asString(r["NAV_ID"]))
+	switch c.Type {
+	case ChangeAdded:
+		return []string{fmt.Sprintf("New %s (%s), %s", subject, asString(r["NAME"]), asString(r["CITY"]))}
+	case ChangeRemoved:
+		return []string{fmt.Sprintf("%s (%s) decommissioned", subject, asString(r["NAME"]))}
+	}
+	var out []string
+	if cc, ok := changed(c, "NAV_STATUS"); ok {
+		if navaidDecommissioned(asString(cc.New)) {
+			out = append(out, fmt.Sprintf("%s (%s) decommissioned", subject, asString(r["NAME"])))
+		} else {
+			out = append(out, fmt.Sprintf("%s status changed %s", subject, arrow(cc)))
+		}
+	}
+	return append(out, columnTexts(c, subject, [][3]string{
+		{"FREQ", "frequency", ""},
+		{"CHAN", "TACAN channel", ""},
+		{"NAME", "name", ""},
+	})...)
+}
+
+// ilsSystems names the ILS_BASE.SYSTEM_TYPE_CODE values.
+var ilsSystems = map[string]string{
+	"LS": "ILS",
+	"LD": "ILS/DME",
+	"LC": "LOC",
+	"LG": "LOC/GS",
+	"DD": "LOC/DME",
+	"LA": "LDA",
+	"LE": "LDA/DME",
+	"SF": "SDF",
+	"SD": "SDF/DME",
+}
+
+func detectILS(c Change, _ *summarizer) []string {
+	r := c.Row
+	system := ilsSystems[asString(r["SYSTEM_TYPE_CODE"])]
+	if system == "" {
+		system = "ILS"
+	}
+	where := fmt.Sprintf("on RWY %s at %s", asString(r["RWY_END_ID"]), asString(r["ARPT_ID"]))
+	switch c.Type {
+	case ChangeAdded:
+		return []string{fmt.Sprintf("New %s %s", system, where)}
+	case ChangeRemoved:
+		return []string{fmt.Sprintf("%s %s %s removed", system, asString(r["ILS_LOC_ID"]), where)}
+	}
+	return columnTexts(c, fmt.Sprintf("%s %s", system, where), [][3]string{
+		{"SYSTEM_TYPE_CODE", "type", ""},
+		{"CATEGORY", "category", ""},
+		{"LOC_FREQ", "frequency", ""},
+		{"APCH_BEAR", "approach course", ""},
+		{"COMPONENT_STATUS", "status", ""},
+	})
+}
+
+func detectAirway(c Change, _ *summarizer) []string {
+	switch c.Type {
+	case ChangeAdded:
+		return []string{"New airway " + asString(c.Row["AWY_ID"])}
+	case ChangeRemoved:
+		return []string{"Airway " + asString(c.Row["AWY_ID"]) + " removed"}
+	}
+	return nil
+}
+
+func detectAirwaySegment(c Change, _ *summarizer) []string {
+	if c.Type != ChangeModified {
+		return nil
+	}
+	r := c.Row
+	segment := fmt.Sprintf("%s segment %s–%s", asString(r["AWY_ID"]), asString(r["FROM_POINT"]), asString(r["TO_POINT"]))
+	var out []string
+	for _, l := range [][2]string{
+		{"MIN_ENROUTE_ALT", "MEA"},
+		{"MIN_ENROUTE_ALT_OPPOSITE", "opposite-direction MEA"},
+		{"GPS_MIN_ENROUTE_ALT", "GPS MEA"},
+		{"MIN_OBSTN_CLNC_ALT", "MOCA"},
+	} {
+		cc, ok := changed(c, l[0])
+		if !ok {
+			continue
+		}
+		verb := "changed"
+		old, oldOK := summaryNumber(cc.Old)
+		cur, curOK := summaryNumber(cc.New)
+		switch {
+		case oldOK && curOK && cur > old:
+			verb = "raised"
+		case oldOK && curOK && cur < old:
+			verb = "lowered"
+		}
+		out = append(out, fmt.Sprintf("%s on %s %s %s", l[1], segment, verb, arrow(cc)))
+	}
+	return out
+}
+
+func detectFix(c Change, s *summarizer) []string {
+	id := asString(c.Row["FIX_ID"])
+	switch c.Type {
+	case ChangeAdded:
+		if old := asString(c.Row["FIX_ID_OLD"]); old != "" {
+			return []string{fmt.Sprintf("Fix %s renamed %s", old, id)}
+		}
+		return []string{"New fix " + id}
+	case ChangeRemoved:
+		if s.renamedFixes[id] {
+			return nil // reported as a rename
+		}
+		return []string{"Fix " + id + " removed"}
+	}
+	_, lat := changed(c, "LAT_DECIMAL")
+	_, lon := changed(c, "LONG_DECIMAL")
+	if lat || lon {
+		return []string{"Fix " + id + " moved"}
+	}
+	return nil
+}
+
+// Groups returns the items grouped by airport, state or ARTCC, in name
+// order with "Other" last.
+func (s *Summary) Groups(by GroupBy) []SummaryGroup {
+	index := map[string]int{}
+	var groups []SummaryGroup
+	for _, it := range s.Items {
+		var name string
+		switch by {
+		case GroupByAirport:
+			name = it.Airport
+		case GroupByState:
+			name = it.State
+		case GroupByARTCC:
+			name = it.ARTCC
+		}
+		if name == "" {
+			name = "Other"
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, SummaryGroup{Name: name})
+		}
+		groups[i].Items = append(groups[i].Items, it)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Name, groups[j].Name
+		if (a == "Other") != (b == "Other") {
+			return b == "Other"
+		}
+		return a < b
+	})
+	return groups
+}
+
+// WriteMarkdown writes the summary as a Markdown document with a section
+// per group.
+func (s *Summary) WriteMarkdown(w io.Writer, by GroupBy) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# NASR changes %s → %s\n", cycleOrUnknown(s.OldCycle), cycleOrUnknown(s.NewCycle))
+	if len(s.Items) == 0 {
+		b.WriteString("\nNo operational changes.\n")
+	}
+	for _, g := range s.Groups(by) {
+		fmt.Fprintf(&b, "\n## %s\n\n", g.Name)
+		for _, it := range g.Items {
+			fmt.Fprintf(&b, "- %s\n", it.Text)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the summary as JSON with the items grouped.
+func (s *Summary) WriteJSON(w io.Writer, by GroupBy) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		OldCycle string         `json:"old_cycle,omitempty"`
+		NewCycle string         `json:"new_cycle,omitempty"`
+		GroupBy  GroupBy        `json:"group_by"`
+		Groups   []SummaryGroup `json:"groups"`
+	}{s.OldCycle, s.NewCycle, by, s.Groups(by)})
+}
+
+func cycleOrUnknown(id string) string {
+	if id == "" {
+		return "unknown"
+	}
+	return id
+}
@@ -0,0 +1,89 @@
+package nasr
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	old, cur := copyTestDB(t), copyTestDB(t)
+	for _, q := range []string{
+		`UPDATE APT_RWY SET RWY_LEN = 5200 WHERE ARPT_ID = '0J0' AND RWY_ID = '18/36'`,
+		`DELETE FROM NAV_BASE WHERE NAV_ID = 'ADW'`,
+		`UPDATE AWY_SEG_ALT SET MIN_ENROUTE_ALT = 19000 WHERE AWY_ID = 'A216' AND FROM_POINT = 'MONPI'`,
+		`UPDATE FIX_BASE SET FIX_ID = 'ZZZZZ', FIX_ID_OLD = 'AAALL' WHERE FIX_ID = 'AAALL'`,
+	} {
+		if _, err := cur.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	if _, err := old.Exec(`DELETE FROM ILS_BASE WHERE ARPT_ID = 'MSS' AND RWY_END_ID = '05'`); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := Diff(old, cur)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	sum, err := Summarize(old, cur, cs)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	items := map[string]SummaryItem{}
+	for _, it := range sum.Items {
+		items[it.Text] = it
+	}
+	for text, want := range map[string]SummaryItem{
+		"Runway 18/36 at 0J0 length changed 5000 → 5200 ft":    {Kind: "runway", Airport: "0J0", State: "AL", ARTCC: "ZJX"},
+		"VORTAC ADW (ANDREWS) decommissioned":                  {Kind: "navaid", State: "MD", ARTCC: "ZDC"},
+		"New ILS on RWY 05 at MSS":                             {Kind: "ils", Airport: "MSS", State: "NY", ARTCC: "ZBW"},
+		"MEA on A216 segment MONPI–OATSS raised 18000 → 19000": {Kind: "airway", State: "OP", ARTCC: "ZAK"},
+		"Fix AAALL renamed ZZZZZ":                              {Kind: "fix", State: "MA", ARTCC: "ZBW"},
+	} {
+		got, ok := items[text]
+		if !ok {
+			t.Errorf("missing %q in %+v", text, sum.Items)
+			continue
+		}
+		got.Text = ""
+		if want.Kind != got.Kind || want.Airport != got.Airport || want.State != got.State || want.ARTCC != got.ARTCC {
+			t.Errorf("%q = %+v, want %+v", text, got, want)
+		}
+	}
+	if _, ok := items["Fix AAALL removed"]; ok {
+		t.Error("renamed fix also reported as removed")
+	}
+
+	groups := sum.Groups(GroupByAirport)
+	if last := groups[len(groups)-1]; last.Name != "Other" {
+		t.Errorf("last airport group = %q, want Other", last.Name)
+	}
+
+	var md bytes.Buffer
+	if err := sum.WriteMarkdown(&md, GroupByState); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# NASR changes 2602 → 2602\n",
+		"## AL\n\n- Runway 18/36 at 0J0 length changed 5000 → 5200 ft\n",
+		"## NY\n\n- New ILS on RWY 05 at MSS\n",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := sum.WriteJSON(&js, GroupByARTCC); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		GroupBy string         `json:"group_by"`
+		Groups  []SummaryGroup `json:"groups"`
+	}
+	if err := json.Unmarshal(js.Bytes(), &doc); err != nil || doc.GroupBy != "artcc" || len(doc.Groups) == 0 {
+		t.Errorf("JSON = %+v, %v", doc, err)
+	}
+}
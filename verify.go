@@ -0,0 +1,305 @@
+package nasr
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/IdahoAvionics/go-nasr/cycle"
+)
+
+// VerifyProblem is a packaging defect found by Verify. Line is 0 when the
+// problem is not on a particular line.
+type VerifyProblem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p VerifyProblem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// VerifyReport is the result of Verify.
+type VerifyReport struct {
+	Source         string          `json:"source"`
+	FullZip        string          `json:"full_zip,omitempty"` // the CSV zip Extract loads
+	Cycle          string          `json:"cycle,omitempty"`    // from FullZip's name, if it has a date
+	DeltaZips      []string        `json:"delta_zips,omitempty"`
+	DataFiles      int             `json:"data_files"`
+	StructureFiles int             `json:"structure_files"`
+	Problems       []VerifyProblem `json:"problems"`
+}
+
+// OK reports whether Verify found no problems.
+func (r *VerifyReport) OK() bool { return len(r.Problems) == 0 }
+
+func (r *VerifyReport) problem(file string, line int, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, VerifyProblem{file, line, fmt.Sprintf(format, args...)})
+}
+
+// Verify checks a NASR subscription zip without loading it:
+//
+//   - every entry of the outer and inner zips reads back with a matching CRC;
+//   - the outer zip has exactly one full CSV_Data/*_CSV.zip (delta zips are
+//     listed but not checked);
+//   - every structure record has its five fields, which Extract otherwise
+//     skips;
+//   - every data CSV has a table in a _CSV_DATA_STRUCTURE.csv file, which
+//     Extract otherwise ignores, and every table has a data CSV;
+//   - every data CSV's header has the structure file's columns in order.
+//
+// The error is non-nil only when src cannot be read as a zip file.
+func Verify(src string) (*VerifyReport, error) {
+	outer, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", src, err)
+	}
+	defer outer.Close()
+
+	r := &VerifyReport{Source: src}
+	var full []*zip.File
+	for _, f := range outer.File {
+		if err := checkCRC(f); err != nil {
+			r.problem(f.Name, 0, "%v", err)
+		}
+		switch isFull, isDelta := csvZipKind(f.Name); {
+		case isFull:
+			full = append(full, f)
+		case isDelta:
+			r.DeltaZips = append(r.DeltaZips, f.Name)
+		}
+	}
+	switch len(full) {
+	case 0:
+		r.problem(filepath.Base(src), 0, "no full CSV_Data/*_CSV.zip")
+		return r, nil
+	case 1:
+	default:
+		names := make([]string, len(full))
+		for i, f := range full {
+			names[i] = f.Name
+		}
+		r.problem(filepath.Base(src), 0, "%d full CSV zips (%s); Extract loads the first", len(full), strings.Join(names, ", "))
+	}
+
+	r.FullZip = full[0].Name
+	if c, err := cycle.ParseFilename(filepath.Base(r.FullZip)); err == nil {
+		r.Cycle = c.ID()
+	}
+	rc, err := full[0].Open()
+	if err != nil {
+		r.problem(r.FullZip, 0, "%v", err)
+		return r, nil
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return r, nil // reported by checkCRC
+	}
+	inner, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		r.problem(r.FullZip, 0, "not a zip file: %v", err)
+		return r, nil
+	}
+	verifyInner(r, inner)
+	return r, nil
+}
+
+// checkCRC reads a zip entry to the end, which makes archive/zip compare
+// the CRC-32 of the data with the one recorded in the archive.
+func checkCRC(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+// verifyInner checks the structure and data files of the full CSV zip.
+func verifyInner(r *VerifyReport, zr *zip.Reader) {
+	type tableDef struct {
+		file    string
+		columns []string
+	}
+	tables := map[string]*tableDef{}
+	var dataFiles []*zip.File
+	for _, f := range zr.File {
+		switch {
+		case strings.HasSuffix(f.Name, "_CSV_DATA_STRUCTURE.csv"):
+			r.StructureFiles++
+			rows, short, err := readStructure(f)
+			if err != nil {
+				r.problem(f.Name, 0, "%v", err)
+				continue
+			}
+			for _, line := range short {
+				r.problem(f.Name, line, "record has fewer than 5 fields")
+			}
+			for _, row := range rows {
+				t, ok := tables[row.table]
+				if !ok {
+					t = &tableDef{file: f.Name}
+					tables[row.table] = t
+				}
+				if t.file != f.Name {
+					r.problem(f.Name, row.line, "table %s is also defined in %s", row.table, t.file)
+					continue
+				}
+				if containsString(t.columns, row.column.name) {
+					r.problem(f.Name, row.line, "column %s.%s defined twice", row.table, row.column.name)
+					continue
+				}
+				t.columns = append(t.columns, row.column.name)
+			}
+		case strings.HasSuffix(f.Name, ".csv"):
+			dataFiles = append(dataFiles, f)
+		default:
+			if err := checkCRC(f); err != nil {
+				r.problem(f.Name, 0, "%v", err)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, f := range dataFiles {
+		r.DataFiles++
+		table := strings.TrimSuffix(filepath.Base(f.Name), ".csv")
+		seen[table] = true
+		header, err := readHeader(f)
+		if err != nil {
+			r.problem(f.Name, 0, "%v", err)
+			continue
+		}
+		t, ok := tables[table]
+		if !ok {
+			r.problem(f.Name, 0, "no _CSV_DATA_STRUCTURE.csv defines table %s; Extract skips this file", table)
+			continue
+		}
+		if msg := compareHeader(header, t.columns); msg != "" {
+			r.problem(f.Name, 1, "header does not match %s: %s", t.file, msg)
+		}
+	}
+
+	var missing []string
+	for table := range tables {
+		if !seen[table] {
+			missing = append(missing, table)
+		}
+	}
+	sort.Strings(missing)
+	for _, table := range missing {
+		r.problem(tables[table].file, 0, "table %s has no data file %s.csv", table, table)
+	}
+}
+
+// readHeader returns the column names in the first line of a data CSV,
+// normalized like structure file column names, and reads the rest of the
+// file to check its CRC.
+func readHeader(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	br := bufio.NewReader(rc)
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+	line, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	header, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for i, name := range header {
+		header[i] = strings.ReplaceAll(strings.TrimSpace(name), " ", "_")
+	}
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// compareHeader describes how a data file header differs from the structure
+// file's columns, or returns "" if they match. Names are compared without
+// regard to case, as SQLite does (CDR.csv has "RCode" for RCODE).
+func compareHeader(header, columns []string) string {
+	var extra, missing []string
+	for _, h := range header {
+		if !containsFold(columns, h) {
+			extra = append(extra, h)
+		}
+	}
+	for _, c := range columns {
+		if !containsFold(header, c) {
+			missing = append(missing, c)
+		}
+	}
+	var parts []string
+	if len(extra) > 0 {
+		parts = append(parts, "unknown columns "+strings.Join(extra, ", "))
+	}
+	if len(missing) > 0 {
+		parts = append(parts, "missing columns "+strings.Join(missing, ", "))
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, "; ")
+	}
+	for i := range header {
+		if !strings.EqualFold(header[i], columns[i]) {
+			return fmt.Sprintf("column %d is %s, expected %s", i+1, header[i], columns[i])
+		}
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes the report for a terminal: the CSV zips found, then OK
+// or one line per problem.
+func (r *VerifyReport) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", r.Source)
+	if r.FullZip != "" {
+		fmt.Fprintf(&b, "  full CSV zip: %s", r.FullZip)
+		if r.Cycle != "" {
+			fmt.Fprintf(&b, " (cycle %s)", r.Cycle)
+		}
+		b.WriteByte('\n')
+	}
+	for _, d := range r.DeltaZips {
+		fmt.Fprintf(&b, "  delta zip:    %s\n", d)
+	}
+	fmt.Fprintf(&b, "  %d data files, %d structure files\n", r.DataFiles, r.StructureFiles)
+	if r.OK() {
+		b.WriteString("OK\n")
+	} else {
+		fmt.Fprintf(&b, "%d problems:\n", len(r.Problems))
+		for _, p := range r.Problems {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,108 @@
+package nasr
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type zipEntry struct {
+	name   string
+	data   string
+	badCRC bool // record a wrong CRC-32 for the entry
+}
+
+func buildZip(t *testing.T, entries []zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		var w io.Writer
+		var err error
+		if e.badCRC {
+			w, err = zw.CreateRaw(&zip.FileHeader{
+				Name: e.name, Method: zip.Store, CRC32: 1,
+				CompressedSize64: uint64(len(e.data)), UncompressedSize64: uint64(len(e.data)),
+			})
+		} else {
+			w, err = zw.Create(e.name)
+		}
+		if err == nil {
+			_, err = io.WriteString(w, e.data)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVerify(t *testing.T) {
+	r, err := Verify(testZipPath)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !r.OK() || r.FullZip != "CSV_Data/19_Feb_2026_CSV.zip" || r.Cycle != "2602" || r.DataFiles != 63 || r.StructureFiles != 24 {
+		t.Errorf("Verify(test zip) = %+v", r)
+	}
+
+	inner := buildZip(t, []zipEntry{
+		{name: "FOO_CSV_DATA_STRUCTURE.csv", data: "CSV File,Column Name,Max Length,Data Type,Nullable\n" +
+			"FOO_BASE,A,1,VARCHAR,No\nFOO_BASE,B,1,VARCHAR,No\nFOO_BASE,C\nFOO_SEG,A,1,VARCHAR,No\n"},
+		{name: "FOO_BASE.csv", data: "\xef\xbb\xbfB,A\n1,2\n"},
+		{name: "BAR.csv", data: "X\n1\n"},
+		{name: "README.txt", data: "corrupt", badCRC: true},
+	})
+	path := filepath.Join(t.TempDir(), "bad.zip")
+	if err := os.WriteFile(path, buildZip(t, []zipEntry{
+		{name: "CSV_Data/19_Feb_2026_CSV.zip", data: string(inner)},
+		{name: "CSV_Data/19_Feb_2026-19_Mar_2026_CSV.zip", data: "delta"},
+		{name: "CSV_Data/22_Jan_2026_CSV.zip", data: "second"},
+	}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = Verify(path)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(r.DeltaZips) != 1 || r.FullZip != "CSV_Data/19_Feb_2026_CSV.zip" {
+		t.Errorf("zips = %s, %v", r.FullZip, r.DeltaZips)
+	}
+	var got []string
+	for _, p := range r.Problems {
+		got = append(got, p.String())
+	}
+	for _, want := range []string{
+		"bad.zip: 2 full CSV zips",
+		"FOO_CSV_DATA_STRUCTURE.csv:4: record has fewer than 5 fields",
+		"FOO_BASE.csv:1: header does not match FOO_CSV_DATA_STRUCTURE.csv: column 1 is B, expected A",
+		"BAR.csv: no _CSV_DATA_STRUCTURE.csv defines table BAR",
+		"FOO_CSV_DATA_STRUCTURE.csv: table FOO_SEG has no data file",
+		"README.txt: zip: checksum error",
+	} {
+		found := false
+		for _, g := range got {
+			found = found || strings.HasPrefix(g, want)
+		}
+		if !found {
+			t.Errorf("missing problem %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+	if len(got) != 6 {
+		t.Errorf("got %d problems, want 6:\n%s", len(got), strings.Join(got, "\n"))
+	}
+
+	var out bytes.Buffer
+	r.WriteText(&out)
+	if !strings.Contains(out.String(), "delta zip:    CSV_Data/19_Feb_2026-19_Mar_2026_CSV.zip") || !strings.Contains(out.String(), "6 problems:") {
+		t.Errorf("WriteText:\n%s", out.String())
+	}
+}
@@ -10,49 +10,56 @@ import (
 
 // openInnerCSVZip opens the outer NASR subscription zip and extracts the inner
 // CSV zip (e.g. CSV_Data/19_Feb_2026_CSV.zip). It returns a zip.Reader over the
-// inner zip and the raw bytes backing it (the caller must keep the bytes alive
-// for the lifetime of the reader).
-func openInnerCSVZip(outerZipPath string) (*zip.Reader, []byte, error) {
+// inner zip, the raw bytes backing it (the caller must keep the bytes alive
+// for the lifetime of the reader) and the inner zip's name.
+func openInnerCSVZip(outerZipPath string) (*zip.Reader, []byte, string, error) {
 	outer, err := zip.OpenReader(outerZipPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("open outer zip: %w", err)
+		return nil, nil, "", fmt.Errorf("open outer zip: %w", err)
 	}
 	defer outer.Close()
 
 	var innerFile *zip.File
 	for _, f := range outer.File {
-		// Match CSV_Data/*_CSV.zip but exclude delta zips that contain a
-		// hyphen between two date strings (e.g. 19_Feb_2026-20_Mar_2026_CSV.zip).
-		if !strings.HasPrefix(f.Name, "CSV_Data/") || !strings.HasSuffix(f.Name, "_CSV.zip") {
-			continue
+		if full, _ := csvZipKind(f.Name); full {
+			innerFile = f
+			break
 		}
-		base := strings.TrimPrefix(f.Name, "CSV_Data/")
-		base = strings.TrimSuffix(base, "_CSV.zip")
-		if strings.Contains(base, "-") {
-			continue // delta zip
-		}
-		innerFile = f
-		break
 	}
 	if innerFile == nil {
-		return nil, nil, fmt.Errorf("no CSV_Data/*_CSV.zip entry found in %s", outerZipPath)
+		return nil, nil, "", fmt.Errorf("no CSV_Data/*_CSV.zip entry found in %s", outerZipPath)
 	}
 
 	rc, err := innerFile.Open()
 	if err != nil {
-		return nil, nil, fmt.Errorf("open inner zip entry %s: %w", innerFile.Name, err)
+		return nil, nil, "", fmt.Errorf("open inner zip entry %s: %w", innerFile.Name, err)
 	}
 	defer rc.Close()
 
 	data, err := io.ReadAll(rc)
 	if err != nil {
-		return nil, nil, fmt.Errorf("read inner zip entry %s: %w", innerFile.Name, err)
+		return nil, nil, "", fmt.Errorf("read inner zip entry %s: %w", innerFile.Name, err)
 	}
 
 	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		return nil, nil, fmt.Errorf("open inner zip reader: %w", err)
+		return nil, nil, "", fmt.Errorf("open inner zip reader: %w", err)
 	}
 
-	return zr, data, nil
+	return zr, data, innerFile.Name, nil
+}
+
+// csvZipKind classifies an outer zip entry. A full CSV zip is
+// CSV_Data/*_CSV.zip; a delta zip has a hyphen between two dates
+// (e.g. 19_Feb_2026-20_Mar_2026_CSV.zip).
+func csvZipKind(name string) (full, delta bool) {
+	if !strings.HasPrefix(name, "CSV_Data/") || !strings.HasSuffix(name, "_CSV.zip") {
+		return false, false
+	}
+	base := strings.TrimPrefix(name, "CSV_Data/")
+	base = strings.TrimSuffix(base, "_CSV.zip")
+	if strings.Contains(base, "-") {
+		return false, true
+	}
+	return true, false
 }